@@ -4,8 +4,18 @@ import fauxgl "github.com/aki-xavier/fauxgl/src"
 
 func main() {
 	scene := fauxgl.CreateScene(1024, 768)
+	scene.Camera.Eye = fauxgl.V(3, -4, 3)
+	scene.Camera.Up = fauxgl.V(0, 0, 1)
+	scene.Camera.LookAt(fauxgl.V(0, 0, 0))
+
 	cube := fauxgl.NewCube()
-	obj := fauxgl.CreateObject(cube, fauxgl.Black)
+	obj := fauxgl.CreateObject(cube, fauxgl.HexColor("#468966"))
+	obj.Matrix = fauxgl.Translate(fauxgl.V(-0.75, 0, 0))
+	scene.AddObject(obj)
+
+	sphere := fauxgl.NewSphere(3)
+	obj = fauxgl.CreateObject(sphere, fauxgl.HexColor("#FFB03B"))
+	obj.Matrix = fauxgl.Scale(fauxgl.V(0.6, 0.6, 0.6)).Translate(fauxgl.V(0.75, 0, 0))
 	scene.AddObject(obj)
 
 	scene.Render()
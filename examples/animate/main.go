@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -39,7 +38,7 @@ func main() {
 	// mesh.SmoothNormalsThreshold(Radians(30))
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 
 	// create transformation matrix and light direction
 	aspect := float64(width) / float64(height)
@@ -59,7 +58,6 @@ func main() {
 
 		// save image
 		image := context.Image()
-		image = resize.Resize(width, height, image, resize.Bilinear)
 		fauxgl.SavePNG(fmt.Sprintf("out/out%03d.png", i), image)
 
 		mesh.Transform(fauxgl.Rotate(up, fauxgl.Radians(5)))
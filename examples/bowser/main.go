@@ -5,7 +5,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -37,7 +36,7 @@ func main() {
 	mesh.SmoothNormalsThreshold(fauxgl.Radians(30))
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(fauxgl.Black)
 
 	// create transformation matrix and light direction
@@ -57,8 +56,12 @@ func main() {
 	fmt.Println(info)
 	fmt.Println(time.Since(start))
 
+	// darken creases with screen-space ambient occlusion
+	ssao := fauxgl.NewSSAO(matrix)
+	ssao.Radius = 0.1
+	context.ApplyAmbientOcclusion(ssao)
+
 	// save image
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	fauxgl.SavePNG("out.png", image)
 }
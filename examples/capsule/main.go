@@ -5,7 +5,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -25,39 +24,44 @@ var (
 )
 
 func main() {
-	// load the mesh
-	mesh, err := fauxgl.LoadOBJ("examples/capsule/capsule.obj")
+	// load the mesh, with its material and texture
+	root, err := fauxgl.LoadOBJScene("examples/capsule/capsule.obj")
 	if err != nil {
 		panic(err)
 	}
 
-	// load the texture
-	texture, err := fauxgl.LoadTexture("examples/capsule/capsule.jpg")
-	if err != nil {
-		panic(err)
-	}
-
-	// fit mesh in a bi-unit cube centered at the origin
-	mesh.BiUnitCube()
+	// fit the model in a bi-unit cube centered at the origin
+	box := fauxgl.EmptyBox
+	root.Walk(func(node *fauxgl.Node, matrix fauxgl.Matrix) {
+		if node.Mesh != nil {
+			box = box.Extend(matrix.MulBox(node.Mesh.BoundingBox()))
+		}
+	})
+	s := 2 / box.Size().MaxComponent()
+	root.Matrix = fauxgl.Translate(box.Center().Negate()).Scale(fauxgl.V(s, s, s))
 
-	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	// create a scene with a supersampled rendering context
+	scene := fauxgl.CreateScene(width, height)
+	scene.Context = fauxgl.NewContextWithSamples(width, height, scale*scale)
+	scene.BackgroundColor = fauxgl.Transparent
+	scene.Root.AddChild(root)
 
-	// create transformation matrix and light direction
-	aspect := float64(width) / float64(height)
-	matrix := fauxgl.LookAt(eye, center, up).Perspective(fovy, aspect, near, far)
+	// set up the camera and light
+	scene.Camera.Eye = eye
+	scene.Camera.Up = up
+	scene.Camera.LookAt(center)
+	scene.Camera.Fovy = fovy
+	scene.Camera.Near = near
+	scene.Camera.Far = far
+	scene.LightDirection = light
 
 	// render
-	shader := fauxgl.NewPhongShader(matrix, light, eye)
-	shader.Texture = texture
-	context.Shader = shader
 	start := time.Now()
-	context.DrawMesh(mesh)
+	scene.Render()
 	fmt.Println(time.Since(start))
 
-	// downsample image for antialiasing
-	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
+	// resolve supersampled image
+	image := scene.Context.Image()
 
 	// save image
 	fauxgl.SavePNG("out.png", image)
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 // download dragon.obj from here:
@@ -61,7 +60,7 @@ func main() {
 	done()
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(background)
 
 	// create transformation matrix and light direction
@@ -76,10 +75,16 @@ func main() {
 	context.DrawMesh(mesh)
 	done()
 
-	// downsample image for antialiasing
-	done = timed("downsampling image")
+	// darken creases with screen-space ambient occlusion
+	done = timed("ambient occlusion")
+	ssao := fauxgl.NewSSAO(matrix)
+	ssao.Radius = 0.1
+	context.ApplyAmbientOcclusion(ssao)
+	done()
+
+	// resolve supersampled image
+	done = timed("resolving image")
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	done()
 
 	// save image
@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -90,7 +89,7 @@ func run(inputPath, outputPath string, xScale, yScale, zScale float64) error {
 	mesh.SmoothNormals()
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(fauxgl.Black)
 
 	// create transformation matrix and light direction
@@ -109,7 +108,6 @@ func run(inputPath, outputPath string, xScale, yScale, zScale float64) error {
 
 	// save image
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	return fauxgl.SavePNG(outputPath, image)
 }
 
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -60,7 +59,7 @@ func main() {
 	mesh.BiUnitCube()
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(fauxgl.HexColor("323"))
 
 	// create transformation matrix and light direction
@@ -79,16 +78,15 @@ func main() {
 
 	context.Shader = fauxgl.NewSolidColorShader(matrix, fauxgl.HexColor("000"))
 	context.Wireframe = true
-	context.LineWidth = scale * 2
+	context.LineWidth = 2
 	context.DepthBias = -4e-5
 	done = timed("rendering lines")
 	context.DrawLines(mesh.Lines)
 	done()
 
-	// downsample image for antialiasing
-	done = timed("downsampling image")
+	// resolve supersampled image
+	done = timed("resolving image")
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	done()
 
 	// save image
@@ -5,7 +5,6 @@ import (
 	"math/rand"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -187,7 +186,7 @@ func main() {
 	aspect := float64(width) / float64(height)
 	matrix := fauxgl.LookAt(eye, center, up).Perspective(fovy, aspect, near, far)
 
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColor = fauxgl.Black
 	context.Shader = fauxgl.NewPhongShader(matrix, light, eye)
 
@@ -224,7 +223,6 @@ func main() {
 		// context.DrawMesh(mesh)
 
 		// image := context.Image()
-		// image = resize.Resize(width, height, image, resize.Bilinear)
 
 		// SavePNG(fmt.Sprintf("frame%06d.png", i), image)
 	}
@@ -243,7 +241,6 @@ func main() {
 	context.DrawMesh(mesh)
 
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 
 	fauxgl.SavePNG("out.png", image)
 }
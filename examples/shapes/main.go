@@ -7,7 +7,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -50,7 +49,7 @@ func main() {
 	}
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(fauxgl.Black)
 
 	// create transformation matrix and light direction
@@ -77,9 +76,8 @@ func main() {
 	context.DepthBias = -0.00001
 	context.DrawMesh(mesh)
 
-	// downsample image for antialiasing
+	// resolve supersampled image
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 
 	// save image
 	fauxgl.SavePNG("out.png", image)
@@ -6,7 +6,6 @@ import (
 	"os"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -138,7 +137,7 @@ func main() {
 	// fmt.Println(len(mesh.Triangles))
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 
 	// create transformation matrix and light direction
 	aspect := float64(width) / float64(height)
@@ -180,10 +179,9 @@ func main() {
 
 	done()
 
-	// downsample image for antialiasing
-	done = timed("downsampling image")
+	// resolve supersampled image
+	done = timed("resolving image")
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	done()
 
 	// save image
@@ -5,7 +5,6 @@ import (
 	"image"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -24,7 +23,7 @@ var (
 )
 
 func render(mesh *fauxgl.Mesh) image.Image {
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColorBufferWith(fauxgl.White)
 
 	aspect := float64(width) / float64(height)
@@ -46,7 +45,6 @@ func render(mesh *fauxgl.Mesh) image.Image {
 	context.DrawMesh(mesh)
 
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 	return image
 }
 
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	fauxgl "github.com/aki-xavier/fauxgl/src"
-	"github.com/nfnt/resize"
 )
 
 const (
@@ -42,7 +41,7 @@ func main() {
 	mesh.BiUnitCube()
 
 	// create a rendering context
-	context := fauxgl.NewContext(width*scale, height*scale)
+	context := fauxgl.NewContextWithSamples(width, height, scale*scale)
 	context.ClearColor = fauxgl.White
 	context.ClearColorBuffer()
 
@@ -58,9 +57,8 @@ func main() {
 	context.DrawMesh(mesh)
 	fmt.Println(time.Since(start))
 
-	// downsample image for antialiasing
+	// resolve supersampled image
 	image := context.Image()
-	image = resize.Resize(width, height, image, resize.Bilinear)
 
 	// save image
 	fauxgl.SavePNG("out.png", image)
@@ -2,7 +2,4 @@ module github.com/aki-xavier/fauxgl
 
 go 1.14
 
-require (
-	github.com/fogleman/simplify v0.0.0-20170216171241-d32f302d5046
-	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
-)
+require github.com/fogleman/simplify v0.0.0-20170216171241-d32f302d5046
@@ -0,0 +1,99 @@
+package fauxgl
+
+import "image"
+
+// MultiShader is a Shader that writes additional outputs for each fragment,
+// one per attachment on the Context. FragmentOutputs returns the color for
+// the color buffer, as Fragment does, and stores the other outputs in
+// outputs, which has one element per attachment in the order they were
+// added. Returning Discard discards every output.
+type MultiShader interface {
+	Shader
+	FragmentOutputs(v Vertex, outputs []Color) Color
+}
+
+// Attachment is an extra render target holding one Color per sample. When a
+// MultiShader is drawn, each fragment that passes the stencil and depth
+// tests stores its outputs in the attachments without blending, except for
+// translucent fragments deferred by TransparencyOrderIndependent.
+//
+// Encoding controls how Image stores colors, as Context.Encoding does for
+// the color buffer. Set it to EncodingLinear for attachments holding data
+// rather than colors, such as positions, depths or ids.
+type Attachment struct {
+	Name     string
+	Width    int
+	Height   int
+	Scale    int
+	Encoding Encoding
+	Buffer   []Color
+}
+
+// AddAttachment adds a render target to the context, cleared to Transparent,
+// with the context's Encoding.
+func (dc *Context) AddAttachment(name string) *Attachment {
+	a := &Attachment{
+		Name:     name,
+		Width:    dc.Width,
+		Height:   dc.Height,
+		Scale:    dc.Scale,
+		Encoding: dc.Encoding,
+		Buffer:   make([]Color, dc.Width*dc.Height),
+	}
+	dc.Attachments = append(dc.Attachments, a)
+	return a
+}
+
+// Attachment returns the attachment with the given name, or nil.
+func (dc *Context) Attachment(name string) *Attachment {
+	for _, a := range dc.Attachments {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Clear :
+func (a *Attachment) Clear(color Color) {
+	for i := range a.Buffer {
+		a.Buffer[i] = color
+	}
+}
+
+// At returns the value at x, y in samples.
+func (a *Attachment) At(x, y int) Color {
+	return a.Buffer[y*a.Width+x]
+}
+
+// Image returns the attachment at the output resolution, with values clamped
+// to [0, 1] and stored with the attachment's Encoding. Use NormalImage for
+// signed values such as normals.
+func (a *Attachment) Image() *image.NRGBA {
+	return a.image(a.Encoding, func(c Color) Color { return c })
+}
+
+// NormalImage returns the attachment at the output resolution with signed
+// values, such as the normals written by GBufferShader, mapped from [-1, 1]
+// to [0, 1] as v * 0.5 + 0.5, like a normal map. The result is always
+// linear, whatever the Encoding. Alpha is stored as is.
+func (a *Attachment) NormalImage() *image.NRGBA {
+	return a.image(EncodingLinear, func(c Color) Color {
+		return Color{c.R*0.5 + 0.5, c.G*0.5 + 0.5, c.B*0.5 + 0.5, c.A}
+	})
+}
+
+func (a *Attachment) image(encoding Encoding, encode func(Color) Color) *image.NRGBA {
+	pixels, w, h := downsample(a.Buffer, a.Width, a.Height, a.Scale)
+	im := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i, c := range pixels {
+		im.SetNRGBA(i%w, i/w, encodeNRGBA(encode(c), encoding))
+	}
+	return im
+}
+
+// SaveHDR writes the attachment at the output resolution as a Radiance RGBE
+// (.hdr) file, preserving values above one.
+func (a *Attachment) SaveHDR(path string) error {
+	return saveHDR(path, a.Buffer, a.Width, a.Height, a.Scale)
+}
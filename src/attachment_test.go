@@ -0,0 +1,33 @@
+package fauxgl
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestAttachmentImageEncoding(t *testing.T) {
+	dc := NewContext(4, 4)
+	albedo := dc.AddAttachment("albedo")
+	position := dc.AddAttachment("position")
+	position.Encoding = EncodingLinear
+	normal := dc.AddAttachment("normal")
+	if albedo.Encoding != EncodingSRGB {
+		t.Fatalf("attachment encoding = %d, want the context's", albedo.Encoding)
+	}
+	c := Color{0.5, 0.5, 0.5, 1}
+	albedo.Clear(c)
+	position.Clear(c)
+	normal.Clear(Color{0, 0, 0, 1})
+
+	// colors are encoded like the color buffer, data is stored linearly
+	dc.ClearColorBufferWith(c)
+	if got, want := albedo.Image().NRGBAAt(1, 1), dc.ColorBuffer.NRGBAAt(1, 1); got != want {
+		t.Errorf("albedo = %v, want %v", got, want)
+	}
+	if got, want := position.Image().NRGBAAt(1, 1), (color.NRGBA{127, 127, 127, 255}); got != want {
+		t.Errorf("position = %v, want %v", got, want)
+	}
+	if got, want := normal.NormalImage().NRGBAAt(1, 1), (color.NRGBA{127, 127, 127, 255}); got != want {
+		t.Errorf("normal = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package fauxgl
+
+import "math"
+
+// BlendFactor :
+type BlendFactor int
+
+// BlendFactors :
+const (
+	_ BlendFactor = iota
+	BlendZero
+	BlendOne
+	BlendSrcColor
+	BlendOneMinusSrcColor
+	BlendDstColor
+	BlendOneMinusDstColor
+	BlendSrcAlpha
+	BlendOneMinusSrcAlpha
+	BlendDstAlpha
+	BlendOneMinusDstAlpha
+)
+
+// BlendEquation :
+type BlendEquation int
+
+// BlendEquations :
+//
+// BlendMin and BlendMax ignore the blend factors, as in OpenGL.
+const (
+	_ BlendEquation = iota
+	BlendAdd
+	BlendSubtract
+	BlendReverseSubtract
+	BlendMin
+	BlendMax
+)
+
+// Blend describes how a fragment is combined with the color buffer when
+// AlphaBlend is enabled. The source and destination colors are both
+// premultiplied by their alpha before the factors are applied, and the
+// result is
+//
+//	Equation(src * Src, dst * Dst)
+//
+// Fields left at zero take their value from BlendSourceOver, so the zero
+// Blend composites the fragment over the existing color.
+type Blend struct {
+	Equation BlendEquation
+	Src      BlendFactor
+	Dst      BlendFactor
+}
+
+// Blend presets :
+var (
+	// BlendSourceOver composites the fragment over the existing color. It
+	// is the default.
+	BlendSourceOver = Blend{BlendAdd, BlendOne, BlendOneMinusSrcAlpha}
+	// BlendAdditive adds the fragment to the existing color.
+	BlendAdditive = Blend{BlendAdd, BlendOne, BlendOne}
+	// BlendMultiply multiplies the existing color by the fragment.
+	BlendMultiply = Blend{BlendAdd, BlendDstColor, BlendOneMinusSrcAlpha}
+	// BlendScreen brightens the existing color by the fragment.
+	BlendScreen = Blend{BlendAdd, BlendOne, BlendOneMinusSrcColor}
+	// BlendLighten keeps the brighter of the two colors.
+	BlendLighten = Blend{BlendMax, BlendOne, BlendOne}
+	// BlendDarken keeps the darker of the two colors.
+	BlendDarken = Blend{BlendMin, BlendOne, BlendOne}
+
+	// blendReplace overwrites the existing color, as when AlphaBlend is
+	// disabled.
+	blendReplace = Blend{BlendAdd, BlendOne, BlendZero}
+)
+
+// Apply blends the premultiplied src color with the premultiplied dst color,
+// returning a premultiplied result. Negative values are clamped to zero and
+// alpha to one, but color values may exceed one for HDR rendering.
+func (b Blend) Apply(src, dst Color) Color {
+	b = b.resolve()
+	var c Color
+	switch b.Equation {
+	case BlendMin:
+		c = src.Min(dst)
+	case BlendMax:
+		c = src.Max(dst)
+	default:
+		s := src.Mul(blendFactor(b.Src, src, dst))
+		d := dst.Mul(blendFactor(b.Dst, src, dst))
+		switch b.Equation {
+		case BlendSubtract:
+			c = s.Sub(d)
+		case BlendReverseSubtract:
+			c = d.Sub(s)
+		default:
+			c = s.Add(d)
+		}
+	}
+	return Color{
+		math.Max(c.R, 0),
+		math.Max(c.G, 0),
+		math.Max(c.B, 0),
+		Clamp(c.A, 0, 1),
+	}
+}
+
+// resolve replaces the zero fields of b with those of BlendSourceOver.
+func (b Blend) resolve() Blend {
+	if b.Equation == 0 {
+		b.Equation = BlendSourceOver.Equation
+	}
+	if b.Src == 0 {
+		b.Src = BlendSourceOver.Src
+	}
+	if b.Dst == 0 {
+		b.Dst = BlendSourceOver.Dst
+	}
+	return b
+}
+
+func blendFactor(f BlendFactor, src, dst Color) Color {
+	switch f {
+	case BlendZero:
+		return Color{}
+	case BlendSrcColor:
+		return src
+	case BlendOneMinusSrcColor:
+		return White.Sub(src)
+	case BlendDstColor:
+		return dst
+	case BlendOneMinusDstColor:
+		return White.Sub(dst)
+	case BlendSrcAlpha:
+		return Color{src.A, src.A, src.A, src.A}
+	case BlendOneMinusSrcAlpha:
+		a := 1 - src.A
+		return Color{a, a, a, a}
+	case BlendDstAlpha:
+		return Color{dst.A, dst.A, dst.A, dst.A}
+	case BlendOneMinusDstAlpha:
+		a := 1 - dst.A
+		return Color{a, a, a, a}
+	}
+	return White
+}
@@ -0,0 +1,118 @@
+package fauxgl
+
+import "testing"
+
+func TestBlendFactors(t *testing.T) {
+	src := Color{0.2, 0.3, 0.4, 0.5}
+	dst := Color{0.6, 0.5, 0.4, 0.8}
+	factors := []struct {
+		f    BlendFactor
+		want Color
+	}{
+		{BlendZero, Color{}},
+		{BlendOne, White},
+		{BlendSrcColor, src},
+		{BlendOneMinusSrcColor, Color{0.8, 0.7, 0.6, 0.5}},
+		{BlendDstColor, dst},
+		{BlendOneMinusDstColor, Color{0.4, 0.5, 0.6, 0.2}},
+		{BlendSrcAlpha, Gray(0.5).Alpha(0.5)},
+		{BlendOneMinusSrcAlpha, Gray(0.5).Alpha(0.5)},
+		{BlendDstAlpha, Gray(0.8).Alpha(0.8)},
+		{BlendOneMinusDstAlpha, Gray(0.2).Alpha(0.2)},
+	}
+	for _, c := range factors {
+		got := Blend{BlendAdd, c.f, BlendZero}.Apply(src, dst)
+		if want := src.Mul(c.want); !colorsEqual(got, want, 1e-9) {
+			t.Errorf("source factor %d: %v, want %v", c.f, got, want)
+		}
+		got = Blend{BlendAdd, BlendZero, c.f}.Apply(src, dst)
+		if want := dst.Mul(c.want); !colorsEqual(got, want, 1e-9) {
+			t.Errorf("destination factor %d: %v, want %v", c.f, got, want)
+		}
+	}
+}
+
+func TestBlendEquations(t *testing.T) {
+	src := Color{0.2, 0.6, 0.4, 0.5}
+	dst := Color{0.6, 0.5, 0.4, 0.8}
+	equations := []struct {
+		e    BlendEquation
+		want Color
+	}{
+		{BlendAdd, Color{0.8, 1.1, 0.8, 1}},
+		{BlendSubtract, Color{0, 0.1, 0, 0}},
+		{BlendReverseSubtract, Color{0.4, 0, 0, 0.3}},
+		{BlendMin, Color{0.2, 0.5, 0.4, 0.5}},
+		{BlendMax, Color{0.6, 0.6, 0.4, 0.8}},
+	}
+	for _, c := range equations {
+		got := Blend{c.e, BlendOne, BlendOne}.Apply(src, dst)
+		if !colorsEqual(got, c.want, 1e-9) {
+			t.Errorf("equation %d: %v, want %v", c.e, got, c.want)
+		}
+	}
+}
+
+func TestBlendZeroValue(t *testing.T) {
+	src := Color{0.2, 0.3, 0.4, 0.5}
+	dst := Color{0.6, 0.5, 0.4, 0.8}
+	want := BlendSourceOver.Apply(src, dst)
+	for _, b := range []Blend{{}, {Equation: BlendAdd}, {Src: BlendOne}, {Dst: BlendOneMinusSrcAlpha}} {
+		if got := b.Apply(src, dst); got != want {
+			t.Errorf("%+v: %v, want source-over %v", b, got, want)
+		}
+	}
+	// a context whose Blend was never set also composites source-over
+	dc := NewContext(4, 4)
+	dc.Blend = Blend{}
+	dc.ClearColorBufferWith(Color{0, 0, 1, 1})
+	dc.Shader = &vertexColorShader{}
+	triangle := NewTriangleForPoints(Vector{-4, -4, 0}, Vector{8, -4, 0}, Vector{-4, 8, 0})
+	triangle.SetColor(Color{1, 0, 0, 0.5})
+	dc.DrawTriangle(triangle)
+	if got := dc.decode(2, 1); !colorsEqual(got, Color{0.5, 0, 0.5, 1}, 1e-2) {
+		t.Errorf("zero context blend: %v, want source-over", got)
+	}
+}
+
+func TestDepthFuncs(t *testing.T) {
+	funcs := []struct {
+		f                      DepthFunc
+		nearer, equal, farther bool
+	}{
+		{DepthNever, false, false, false},
+		{DepthLess, true, false, false},
+		{DepthEqual, false, true, false},
+		{DepthLEqual, true, true, false},
+		{DepthGreater, false, false, true},
+		{DepthNotEqual, true, false, true},
+		{DepthGEqual, false, true, true},
+		{DepthAlways, true, true, true},
+	}
+	near := NewTriangleForPoints(Vector{-4, -4, -0.5}, Vector{8, -4, -0.5}, Vector{-4, 8, -0.5})
+	near.SetColor(Color{1, 0, 0, 1})
+	mid := NewTriangleForPoints(Vector{-4, -4, 0}, Vector{8, -4, 0}, Vector{-4, 8, 0})
+	mid.SetColor(Color{0, 1, 0, 1})
+	far := NewTriangleForPoints(Vector{-4, -4, 0.5}, Vector{8, -4, 0.5}, Vector{-4, 8, 0.5})
+	far.SetColor(Color{0, 0, 1, 1})
+	for _, c := range funcs {
+		if c.f.Test(1, 2) != c.nearer || c.f.Test(2, 2) != c.equal || c.f.Test(3, 2) != c.farther {
+			t.Errorf("func %d gives %v %v %v, want %v %v %v", c.f,
+				c.f.Test(1, 2), c.f.Test(2, 2), c.f.Test(3, 2), c.nearer, c.equal, c.farther)
+		}
+		// draw each depth over a triangle in the middle
+		for _, d := range []struct {
+			t    *Triangle
+			pass bool
+		}{{near, c.nearer}, {mid, c.equal}, {far, c.farther}} {
+			dc := NewContext(4, 4)
+			dc.Shader = &vertexColorShader{}
+			dc.DrawTriangle(mid)
+			dc.DepthFunc = c.f
+			info := dc.DrawTriangle(d.t)
+			if got := info.UpdatedPixels > 0; got != d.pass {
+				t.Errorf("func %d drawing %v over the middle: drawn = %v", c.f, d.t.V1.Color, got)
+			}
+		}
+	}
+}
@@ -4,7 +4,11 @@ package fauxgl
 type Camera struct {
 	Eye       Vector
 	Direction Vector
+	Up        Vector
 	Distance  float64
+	Fovy      float64
+	Near      float64
+	Far       float64
 }
 
 // CreateCamera :
@@ -13,5 +17,37 @@ func CreateCamera() *Camera {
 	c.Distance = 5
 	c.Eye = Vector{0, 0, 10}
 	c.Direction = Vector{0, 0, -1}
+	c.Up = Vector{0, 1, 0}
+	c.Fovy = 30
+	c.Near = 1
+	c.Far = 100
 	return c
 }
+
+// Center returns the point the camera is looking at, Distance units
+// along Direction from Eye.
+func (c *Camera) Center() Vector {
+	return c.Eye.Add(c.Direction.Normalize().MulScalar(c.Distance))
+}
+
+// LookAt points the camera at center, updating Direction and Distance.
+func (c *Camera) LookAt(center Vector) {
+	d := center.Sub(c.Eye)
+	c.Distance = d.Length()
+	c.Direction = d.Normalize()
+}
+
+// ViewMatrix :
+func (c *Camera) ViewMatrix() Matrix {
+	return LookAt(c.Eye, c.Eye.Add(c.Direction), c.Up)
+}
+
+// ProjectionMatrix :
+func (c *Camera) ProjectionMatrix(aspect float64) Matrix {
+	return Perspective(c.Fovy, aspect, c.Near, c.Far)
+}
+
+// Matrix returns the combined view and projection matrix.
+func (c *Camera) Matrix(aspect float64) Matrix {
+	return c.ViewMatrix().Perspective(c.Fovy, aspect, c.Near, c.Far)
+}
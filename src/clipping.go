@@ -18,11 +18,17 @@ func (p clipPlane) pointInFront(v VectorW) bool {
 }
 
 func (p clipPlane) intersectSegment(v0, v1 VectorW) VectorW {
+	return v0.Add(v1.Sub(v0).MulScalar(p.segmentParameter(v0, v1)))
+}
+
+// segmentParameter returns how far along the segment from v0 to v1 it
+// crosses the plane, from 0 at v0 to 1 at v1.
+func (p clipPlane) segmentParameter(v0, v1 VectorW) float64 {
 	u := v1.Sub(v0)
 	w := v0.Sub(p.P)
 	d := p.N.Dot(u)
 	n := -p.N.Dot(w)
-	return v0.Add(u.MulScalar(n / d))
+	return n / d
 }
 
 func sutherlandHodgman(points []VectorW, planes []clipPlane) []VectorW {
@@ -76,25 +82,31 @@ func ClipTriangle(t *Triangle) []*Triangle {
 
 // ClipLine :
 func ClipLine(l *Line) *Line {
-	// TODO: interpolate vertex attributes when clipped
-	w1 := l.V1.Output
-	w2 := l.V2.Output
+	// t1 and t2 are the clipped ends, as fractions of the way from V1 to V2
+	t1, t2 := 0.0, 1.0
+	u := l.V2.Output.Sub(l.V1.Output)
 	for _, plane := range clipPlanes {
+		w1 := l.V1.Output.Add(u.MulScalar(t1))
+		w2 := l.V1.Output.Add(u.MulScalar(t2))
 		f1 := plane.pointInFront(w1)
 		f2 := plane.pointInFront(w2)
 		if f1 && f2 {
 			continue
 		} else if f1 {
-			w2 = plane.intersectSegment(w1, w2)
+			t2 = t1 + (t2-t1)*plane.segmentParameter(w1, w2)
 		} else if f2 {
-			w1 = plane.intersectSegment(w2, w1)
+			t1 = t2 + (t1-t2)*plane.segmentParameter(w2, w1)
 		} else {
 			return nil
 		}
 	}
 	v1 := l.V1
 	v2 := l.V2
-	v1.Output = w1
-	v2.Output = w2
+	if t1 != 0 {
+		v1 = InterpolateVertexes(l.V1, l.V2, l.V2, VectorW{1 - t1, t1, 0, 1})
+	}
+	if t2 != 1 {
+		v2 = InterpolateVertexes(l.V1, l.V2, l.V2, VectorW{1 - t2, t2, 0, 1})
+	}
 	return NewLine(v1, v2)
 }
@@ -0,0 +1,44 @@
+package fauxgl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClipLineVaryings(t *testing.T) {
+	v1 := Vertex{Output: VectorW{-2, 0, 0, 1}, Color: Black, Floats: []float64{0}}
+	v2 := Vertex{Output: VectorW{2, 0, 0, 1}, Color: White, Floats: []float64{1}}
+	line := ClipLine(NewLine(v1, v2))
+	if line == nil {
+		t.Fatal("line was clipped away")
+	}
+	ends := []struct {
+		v    Vertex
+		x, f float64
+	}{
+		{line.V1, -1, 0.25},
+		{line.V2, 1, 0.75},
+	}
+	for i, e := range ends {
+		if math.Abs(e.v.Output.X-e.x) > 1e-9 {
+			t.Errorf("end %d at x = %g, want %g", i, e.v.Output.X, e.x)
+		}
+		if math.Abs(e.v.Floats[0]-e.f) > 1e-9 || math.Abs(e.v.Color.R-e.f) > 1e-9 {
+			t.Errorf("end %d varyings = %v, %v, want %g", i, e.v.Floats, e.v.Color, e.f)
+		}
+	}
+
+	// an unclipped line keeps its vertices
+	v2.Output = VectorW{0.5, 0, 0, 1}
+	v1.Output = VectorW{-0.5, 0, 0, 1}
+	line = ClipLine(NewLine(v1, v2))
+	if line.V1.Floats[0] != 0 || line.V2.Floats[0] != 1 {
+		t.Errorf("unclipped varyings = %v, %v", line.V1.Floats, line.V2.Floats)
+	}
+
+	v1.Output = VectorW{2, 0, 0, 1}
+	v2.Output = VectorW{3, 0, 0, 1}
+	if ClipLine(NewLine(v1, v2)) != nil {
+		t.Error("line outside the view was not clipped away")
+	}
+}
@@ -28,14 +28,71 @@ func Gray(x float64) Color {
 	return Color{x, x, x, 1}
 }
 
-// MakeColor :
+// Encoding describes how color values are stored in an 8-bit image.
+type Encoding int
+
+// Encodings :
+const (
+	_ Encoding = iota
+	EncodingLinear
+	EncodingSRGB
+)
+
+// srgbTable maps 8-bit sRGB values to linear intensities.
+var srgbTable = func() (table [256]float64) {
+	for i := range table {
+		table[i] = srgbToLinear(float64(i) / 0xff)
+	}
+	return
+}()
+
+func srgbToLinear(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(x float64) float64 {
+	if x <= 0.0031308 {
+		return 12.92 * x
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
+
+// encodeNRGBA converts a straight alpha linear color to 8 bits with the
+// given encoding.
+func encodeNRGBA(c Color, e Encoding) color.NRGBA {
+	if e != EncodingSRGB {
+		return c.NRGBA()
+	}
+	const d = 0xff
+	r := linearToSRGB(Clamp(c.R, 0, 1))
+	g := linearToSRGB(Clamp(c.G, 0, 1))
+	b := linearToSRGB(Clamp(c.B, 0, 1))
+	a := Clamp(c.A, 0, 1)
+	// round rather than truncate so that 8-bit colors survive a round trip
+	return color.NRGBA{uint8(r*d + 0.5), uint8(g*d + 0.5), uint8(b*d + 0.5), uint8(a*d + 0.5)}
+}
+
+// MakeColor converts c, which is assumed to be sRGB encoded, to a linear
+// Color premultiplied by its alpha.
 func MakeColor(c color.Color) Color {
 	r, g, b, a := c.RGBA()
 	const d = 0xffff
-	return Color{float64(r) / d, float64(g) / d, float64(b) / d, float64(a) / d}
+	if a == 0 {
+		return Color{}
+	}
+	fa := float64(a) / d
+	return Color{
+		srgbToLinear(float64(r)/float64(a)) * fa,
+		srgbToLinear(float64(g)/float64(a)) * fa,
+		srgbToLinear(float64(b)/float64(a)) * fa,
+		fa,
+	}
 }
 
-// HexColor :
+// HexColor parses an sRGB hex color such as "#ff8000" into a linear Color.
 func HexColor(x string) Color {
 	x = strings.Trim(x, "#")
 	var r, g, b, a int
@@ -57,8 +114,7 @@ func HexColor(x string) Color {
 	case 8:
 		fmt.Sscanf(x, "%02x%02x%02x%02x", &r, &g, &b, &a)
 	}
-	const d = 0xff
-	return Color{float64(r) / d, float64(g) / d, float64(b) / d, float64(a) / d}
+	return Color{srgbTable[r], srgbTable[g], srgbTable[b], float64(a) / 0xff}
 }
 
 // NRGBA :
@@ -71,6 +127,18 @@ func (c Color) NRGBA() color.NRGBA {
 	return color.NRGBA{uint8(r * d), uint8(g * d), uint8(b * d), uint8(a * d)}
 }
 
+// Linear converts an sRGB encoded color to linear intensities. Alpha is
+// unchanged.
+func (c Color) Linear() Color {
+	return Color{srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B), c.A}
+}
+
+// SRGB encodes a linear color with the sRGB transfer function. Alpha is
+// unchanged.
+func (c Color) SRGB() Color {
+	return Color{linearToSRGB(c.R), linearToSRGB(c.G), linearToSRGB(c.B), c.A}
+}
+
 // Opaque :
 func (c Color) Opaque() Color {
 	return Color{c.R, c.G, c.B, 1}
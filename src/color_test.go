@@ -0,0 +1,80 @@
+package fauxgl
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestSRGBRoundTrip(t *testing.T) {
+	// every 8-bit value survives decoding and encoding again
+	for i := 0; i < 256; i++ {
+		v := uint8(i)
+		c := Color{srgbTable[i], srgbTable[i], srgbTable[i], float64(i) / 0xff}
+		if got := encodeNRGBA(c, EncodingSRGB); got != (color.NRGBA{v, v, v, v}) {
+			t.Fatalf("sRGB %d encoded as %v", i, got)
+		}
+		c = Color{float64(i) / 0xff, 0, 0, 1}
+		if got := encodeNRGBA(c, EncodingLinear); got.R != v {
+			t.Fatalf("linear %d encoded as %v", i, got)
+		}
+	}
+	for _, x := range []float64{0, 0.001, 0.0031308, 0.2, 0.5, 1} {
+		c := Color{x, x / 2, x / 3, 0.5}
+		if got := c.SRGB().Linear(); !colorsEqual(got, c, 1e-12) {
+			t.Errorf("%v round tripped to %v", c, got)
+		}
+	}
+}
+
+func TestSRGBValues(t *testing.T) {
+	cases := []struct {
+		linear float64
+		srgb   uint8
+	}{
+		{0, 0},
+		{0.001, 3},
+		{0.2159, 128},
+		{0.5, 188},
+		{1, 255},
+	}
+	for _, c := range cases {
+		got := encodeNRGBA(Color{c.linear, 0, 0, 1}, EncodingSRGB).R
+		if got != c.srgb {
+			t.Errorf("linear %g encoded as %d, want %d", c.linear, got, c.srgb)
+		}
+		if d := math.Abs(srgbTable[c.srgb] - c.linear); d > 3e-3 {
+			t.Errorf("sRGB %d decoded as %g, want %g", c.srgb, srgbTable[c.srgb], c.linear)
+		}
+	}
+
+	// MakeColor and HexColor decode to linear, and MakeColor premultiplies
+	if got, want := MakeColor(color.NRGBA{188, 0, 255, 128}), (Color{0.25, 0, 0.5, 0.5}); !colorsEqual(got, want, 3e-3) {
+		t.Errorf("MakeColor = %v, want %v", got, want)
+	}
+	if got, want := HexColor("#bc00ff80"), (Color{0.5, 0, 1, 0.5}); !colorsEqual(got, want, 3e-3) {
+		t.Errorf("HexColor = %v, want %v", got, want)
+	}
+}
+
+func TestContextEncoding(t *testing.T) {
+	gray := Color{0.5, 0.5, 0.5, 1}
+	cases := []struct {
+		encoding Encoding
+		want     uint8
+	}{
+		{EncodingSRGB, 188},
+		{EncodingLinear, 127},
+	}
+	for _, c := range cases {
+		dc := NewContext(2, 2)
+		dc.Encoding = c.encoding
+		dc.ClearColorBufferWith(gray)
+		if got := dc.ColorBuffer.NRGBAAt(0, 0); got.R != c.want || got.A != 255 {
+			t.Errorf("encoding %d stored %v, want %d", c.encoding, got, c.want)
+		}
+		if got := dc.decode(1, 1); !colorsEqual(got, gray, 5e-3) {
+			t.Errorf("encoding %d decoded %v, want %v", c.encoding, got, gray)
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package fauxgl
 
 import (
+	"fmt"
 	"image"
+	"image/color"
 	"math"
-	"runtime"
-	"sync"
 )
 
 // Face :
@@ -28,7 +28,46 @@ const (
 	CullBack
 )
 
-// RasterizeInfo :
+// DepthFunc :
+type DepthFunc int
+
+// DepthFuncs :
+const (
+	_ DepthFunc = iota
+	DepthNever
+	DepthLess
+	DepthEqual
+	DepthLEqual
+	DepthGreater
+	DepthNotEqual
+	DepthGEqual
+	DepthAlways
+)
+
+// Test reports whether a fragment at depth z passes against the stored depth.
+func (f DepthFunc) Test(z, depth float64) bool {
+	switch f {
+	case DepthNever:
+		return false
+	case DepthLess:
+		return z < depth
+	case DepthEqual:
+		return z == depth
+	case DepthGreater:
+		return z > depth
+	case DepthNotEqual:
+		return z != depth
+	case DepthGEqual:
+		return z >= depth
+	case DepthAlways:
+		return true
+	}
+	return z <= depth
+}
+
+// RasterizeInfo counts the pixels covered by drawn primitives that pass the
+// stencil test, and of those, the pixels that also pass the depth test and
+// are not discarded by the shader.
 type RasterizeInfo struct {
 	TotalPixels   uint64
 	UpdatedPixels uint64
@@ -43,58 +82,153 @@ func (info RasterizeInfo) Add(other RasterizeInfo) RasterizeInfo {
 }
 
 // Context :
+//
+// A supersampled context, created with NewContextWithSamples, renders into
+// buffers that are Scale times larger than the output in each dimension;
+// Width, Height and the buffers are all at that sampling resolution. Image
+// resolves them down to the output resolution with the Resolve filter.
+// LineWidth is always given in output pixels.
+//
+// Colors are computed and blended as linear intensities. Encoding controls
+// how they are stored in the 8-bit ColorBuffer: EncodingSRGB, the default,
+// applies the sRGB transfer function, while EncodingLinear stores them
+// unchanged.
+//
+// When HDR is set, colors are accumulated without clamping in HDRBuffer,
+// which holds premultiplied linear values, instead of ColorBuffer. Image
+// then applies the Exposure (in stops), the ToneMap operator and the
+// Encoding to produce the color buffer.
+//
+// StencilBuffer is nil until it is first needed, either by clearing it or
+// by drawing with StencilTest enabled, in which case it starts out zeroed.
+//
+// When WriteID is set, every pixel whose color is written also records
+// ObjectID in IDBuffer and the index of the triangle or line within its draw
+// call in PrimitiveBuffer; DrawMesh numbers the mesh's lines after its
+// triangles. Both buffers are allocated on first use and hold -1 where
+// nothing has been drawn.
 type Context struct {
-	Width        int
-	Height       int
-	ColorBuffer  *image.NRGBA
-	DepthBuffer  []float64
-	ClearColor   Color
-	Shader       Shader
-	ReadDepth    bool
-	WriteDepth   bool
-	WriteColor   bool
-	AlphaBlend   bool
-	Wireframe    bool
-	FrontFace    Face
-	Cull         Cull
-	LineWidth    float64
-	DepthBias    float64
-	screenMatrix Matrix
-	locks        []sync.Mutex
+	Width            int
+	Height           int
+	Scale            int
+	Resolve          Resolve
+	ColorBuffer      *image.NRGBA
+	Encoding         Encoding
+	HDR              bool
+	HDRBuffer        []Color
+	ToneMap          ToneMap
+	Exposure         float64
+	DepthBuffer      []float64
+	StencilBuffer    []uint8
+	IDBuffer         []int32
+	PrimitiveBuffer  []int32
+	Attachments      []*Attachment
+	ClearColor       Color
+	Shader           Shader
+	ReadDepth        bool
+	DepthFunc        DepthFunc
+	WriteDepth       bool
+	WriteColor       bool
+	WriteID          bool
+	ObjectID         int32
+	AlphaBlend       bool
+	Blend            Blend
+	StencilTest      bool
+	StencilFunc      StencilFunc
+	StencilRef       uint8
+	StencilMask      uint8
+	StencilWriteMask uint8
+	StencilFail      StencilOp
+	StencilDepthFail StencilOp
+	StencilPass      StencilOp
+	Wireframe        bool
+	FrontFace        Face
+	Cull             Cull
+	LineWidth        float64
+	DepthBias        float64
+	Transparency     Transparency
+	screenMatrix     Matrix
+	fragments        [][]fragment
 }
 
 // NewContext :
 func NewContext(width, height int) *Context {
+	return NewContextWithSamples(width, height, 1)
+}
+
+// NewContextWithSamples creates a context that renders samples samples per
+// output pixel, on a grid of sqrt(samples) by sqrt(samples). It panics if
+// samples is not a positive square number, such as 1, 4, 9 or 16.
+func NewContextWithSamples(width, height, samples int) *Context {
+	scale := int(math.Sqrt(float64(samples)) + 0.5)
+	if samples < 1 || scale*scale != samples {
+		panic(fmt.Sprintf("fauxgl: sample count %d is not a positive square number", samples))
+	}
+	width *= scale
+	height *= scale
 	dc := &Context{}
 	dc.Width = width
 	dc.Height = height
+	dc.Scale = scale
+	dc.Resolve = ResolveBox
 	dc.ColorBuffer = image.NewNRGBA(image.Rect(0, 0, width, height))
+	dc.Encoding = EncodingSRGB
+	dc.ToneMap = ToneMapACES
 	dc.DepthBuffer = make([]float64, width*height)
 	dc.ClearColor = Transparent
 	dc.Shader = NewSolidColorShader(Identity(), Color{1, 0, 1, 1})
 	dc.ReadDepth = true
+	dc.DepthFunc = DepthLEqual
 	dc.WriteDepth = true
 	dc.WriteColor = true
+	dc.WriteID = false
 	dc.AlphaBlend = true
+	dc.Blend = BlendSourceOver
+	dc.StencilTest = false
+	dc.StencilFunc = StencilAlways
+	dc.StencilMask = 0xff
+	dc.StencilWriteMask = 0xff
+	dc.StencilFail = StencilKeep
+	dc.StencilDepthFail = StencilKeep
+	dc.StencilPass = StencilKeep
 	dc.Wireframe = false
 	dc.FrontFace = FaceCCW
 	dc.Cull = CullBack
 	dc.LineWidth = 2
 	dc.DepthBias = 0
+	dc.Transparency = TransparencyOrdered
 	dc.screenMatrix = Screen(width, height)
-	dc.locks = make([]sync.Mutex, 256)
 	dc.ClearDepthBuffer()
 	return dc
 }
 
-// Image :
+// Image returns the color buffer, resolved to the output resolution if the
+// context is supersampled. Note that it modifies the context: any pending
+// translucent fragments are composited into the color buffer first, and HDR
+// contexts are tone mapped into the color buffer. The image returned for
+// a context without supersampling is the color buffer itself.
 func (dc *Context) Image() image.Image {
-	return dc.ColorBuffer
+	dc.CompositeFragments()
+	if dc.HDR && dc.HDRBuffer != nil {
+		dc.toneMap()
+	}
+	if dc.Scale <= 1 {
+		return dc.ColorBuffer
+	}
+	return resolve(dc.ColorBuffer, dc.Scale, dc.Resolve, dc.Encoding)
 }
 
 // ClearColorBufferWith :
 func (dc *Context) ClearColorBufferWith(color Color) {
-	c := color.NRGBA()
+	dc.clearFragments()
+	if dc.HDR {
+		dc.allocateBuffers()
+		p := color.MulScalar(color.A).Alpha(color.A)
+		for i := range dc.HDRBuffer {
+			dc.HDRBuffer[i] = p
+		}
+	}
+	c := dc.encode(color)
 	for y := 0; y < dc.Height; y++ {
 		i := dc.ColorBuffer.PixOffset(0, y)
 		for x := 0; x < dc.Width; x++ {
@@ -112,6 +246,98 @@ func (dc *Context) ClearColorBuffer() {
 	dc.ClearColorBufferWith(dc.ClearColor)
 }
 
+// blend returns the blend state that fragments are written with.
+func (dc *Context) blend() Blend {
+	if !dc.AlphaBlend {
+		return blendReplace
+	}
+	return dc.Blend.resolve()
+}
+
+func (dc *Context) writeColor(x, y int, color Color, blend Blend) {
+	if dc.HDR {
+		dc.writeHDR(x, y, color, blend)
+		return
+	}
+	color = Color{
+		Clamp(color.R, 0, 1),
+		Clamp(color.G, 0, 1),
+		Clamp(color.B, 0, 1),
+		Clamp(color.A, 0, 1),
+	}
+	if blend == blendReplace || color.A >= 1 && blend == BlendSourceOver {
+		dc.ColorBuffer.SetNRGBA(x, y, dc.encode(color))
+		return
+	}
+	// blend in premultiplied linear space
+	src := color.MulScalar(color.A).Alpha(color.A)
+	dst := dc.decode(x, y)
+	dst = dst.MulScalar(dst.A).Alpha(dst.A)
+	c := blend.Apply(src, dst)
+	if c.A > 0 {
+		c = c.DivScalar(c.A).Alpha(c.A)
+	}
+	dc.ColorBuffer.SetNRGBA(x, y, dc.encode(c))
+}
+
+// encode converts a straight alpha linear color to the color buffer's
+// encoding.
+func (dc *Context) encode(c Color) color.NRGBA {
+	return encodeNRGBA(c, dc.Encoding)
+}
+
+// decode returns the straight alpha linear color at x, y in the color buffer.
+func (dc *Context) decode(x, y int) Color {
+	const d = 1.0 / 0xff
+	i := dc.ColorBuffer.PixOffset(x, y)
+	p := dc.ColorBuffer.Pix[i : i+4 : i+4]
+	if dc.Encoding != EncodingSRGB {
+		return Color{float64(p[0]) * d, float64(p[1]) * d, float64(p[2]) * d, float64(p[3]) * d}
+	}
+	return Color{srgbTable[p[0]], srgbTable[p[1]], srgbTable[p[2]], float64(p[3]) * d}
+}
+
+func (dc *Context) writeHDR(x, y int, color Color, blend Blend) {
+	i := y*dc.Width + x
+	a := Clamp(color.A, 0, 1)
+	src := color.MulScalar(a).Alpha(a)
+	if blend == blendReplace || a >= 1 && blend == BlendSourceOver {
+		dc.HDRBuffer[i] = src
+		return
+	}
+	dc.HDRBuffer[i] = blend.Apply(src, dc.HDRBuffer[i])
+}
+
+// allocateBuffers creates the optional buffers needed by the current
+// settings. It must be called before rasterizing in parallel.
+func (dc *Context) allocateBuffers() {
+	if dc.HDR && dc.HDRBuffer == nil {
+		dc.HDRBuffer = make([]Color, dc.Width*dc.Height)
+	}
+	if dc.WriteID && dc.IDBuffer == nil {
+		dc.ClearIDBuffer()
+	}
+	if dc.StencilTest && dc.StencilBuffer == nil {
+		dc.StencilBuffer = make([]uint8, dc.Width*dc.Height)
+	}
+	if dc.Transparency == TransparencyOrderIndependent && dc.fragments == nil {
+		dc.fragments = make([][]fragment, dc.Width*dc.Height)
+	}
+}
+
+// ClearIDBuffer resets the object and primitive id buffers to -1,
+// allocating them if needed.
+func (dc *Context) ClearIDBuffer() {
+	if dc.IDBuffer == nil {
+		dc.IDBuffer = make([]int32, dc.Width*dc.Height)
+		dc.PrimitiveBuffer = make([]int32, dc.Width*dc.Height)
+	}
+	for i := range dc.IDBuffer {
+		dc.IDBuffer[i] = -1
+		dc.PrimitiveBuffer[i] = -1
+	}
+}
+
 // ClearDepthBufferWith :
 func (dc *Context) ClearDepthBufferWith(value float64) {
 	for i := range dc.DepthBuffer {
@@ -128,22 +354,65 @@ func edge(a, b, c Vector) float64 {
 	return (b.X-c.X)*(a.Y-c.Y) - (b.Y-c.Y)*(a.X-c.X)
 }
 
-func (dc *Context) rasterize(v0, v1, v2 Vertex, s0, s1, s2 Vector) RasterizeInfo {
+// subpixels is the precision that screen coordinates are snapped to. With
+// coordinates on this grid the edge functions are computed exactly, so
+// triangles that share an edge agree on which pixels each one covers, no
+// matter which tile or bounding box they are rasterized from.
+const subpixels = 256
+
+func snap(v Vector) Vector {
+	v.X = math.Round(v.X*subpixels) / subpixels
+	v.Y = math.Round(v.Y*subpixels) / subpixels
+	return v
+}
+
+// appendPrimitive snaps the screen coordinates of p and appends it, unless
+// snapping collapsed it to zero area.
+func appendPrimitive(primitives []primitive, p primitive) []primitive {
+	p.s0, p.s1, p.s2 = snap(p.s0), snap(p.s1), snap(p.s2)
+	if edge(p.s0, p.s1, p.s2) == 0 {
+		return primitives
+	}
+	return append(primitives, p)
+}
+
+func interpolateTexture(v0, v1, v2 Vertex, b0, b1, b2, r0, r1, r2 float64) Vector {
+	b := VectorW{b0 * r0, b1 * r1, b2 * r2, 0}
+	b.W = 1 / (b.X + b.Y + b.Z)
+	return InterpolateVectors(v0.Texture, v1.Texture, v2.Texture, b)
+}
+
+// outputs returns a buffer for the extra outputs of a MultiShader, to be
+// reused by every fragment that one goroutine rasterizes, or nil if there
+// are none.
+func (dc *Context) outputs() []Color {
+	if _, ok := dc.Shader.(MultiShader); ok && len(dc.Attachments) > 0 {
+		return make([]Color, len(dc.Attachments))
+	}
+	return nil
+}
+
+func (dc *Context) rasterize(p *primitive, bounds image.Rectangle, outputs []Color) RasterizeInfo {
 	var info RasterizeInfo
+	v0, v1, v2 := p.v0, p.v1, p.v2
+	s0, s1, s2 := p.s0, p.s1, p.s2
 
-	// integer bounding box
+	// integer bounding box, clipped to the bounds being drawn
 	min := s0.Min(s1.Min(s2)).Floor()
 	max := s0.Max(s1.Max(s2)).Ceil()
-	x0 := int(min.X)
-	x1 := int(max.X)
-	y0 := int(min.Y)
-	y1 := int(max.Y)
+	x0 := ClampInt(int(min.X), bounds.Min.X, bounds.Max.X)
+	x1 := ClampInt(int(max.X), bounds.Min.X-1, bounds.Max.X-1)
+	y0 := ClampInt(int(min.Y), bounds.Min.Y, bounds.Max.Y)
+	y1 := ClampInt(int(max.Y), bounds.Min.Y-1, bounds.Max.Y-1)
+	if x0 > x1 || y0 > y1 {
+		return info
+	}
 
 	// forward differencing variables
-	p := Vector{float64(x0) + 0.5, float64(y0) + 0.5, 0}
-	w00 := edge(s1, s2, p)
-	w01 := edge(s2, s0, p)
-	w02 := edge(s0, s1, p)
+	q := Vector{float64(x0) + 0.5, float64(y0) + 0.5, 0}
+	w00 := edge(s1, s2, q)
+	w01 := edge(s2, s0, q)
+	w02 := edge(s0, s1, q)
 	a01 := s1.Y - s0.Y
 	b01 := s0.X - s1.X
 	a12 := s2.Y - s1.Y
@@ -160,6 +429,13 @@ func (dc *Context) rasterize(v0, v1, v2 Vertex, s0, s1, s2 Vector) RasterizeInfo
 	ra20 := 1 / a20
 	ra01 := 1 / a01
 
+	// texture derivatives are only needed for textured primitives
+	var zero Vector
+	textured := v0.Texture != zero || v1.Texture != zero || v2.Texture != zero
+
+	// extra outputs for multiple render targets
+	multi, _ := dc.Shader.(MultiShader)
+
 	// iterate over all pixels in bounding box
 	for y := y0; y <= y1; y++ {
 		var d float64
@@ -199,58 +475,73 @@ func (dc *Context) rasterize(v0, v1, v2 Vertex, s0, s1, s2 Vector) RasterizeInfo
 				continue
 			}
 			wasInside = true
-			// check depth buffer for early abort
+			// check stencil and depth buffers for early abort
 			i := y*dc.Width + x
-			if i < 0 || i >= len(dc.DepthBuffer) {
-				// TODO: clipping roundoff error; fix
-				// TODO: could also be from fat lines going off screen
+			if dc.StencilTest && !dc.stencilTest(i) {
 				continue
 			}
 			info.TotalPixels++
 			z := b0*s0.Z + b1*s1.Z + b2*s2.Z
 			bz := z + dc.DepthBias
-			if dc.ReadDepth && bz > dc.DepthBuffer[i] { // safe w/out lock?
+			if dc.ReadDepth && !dc.DepthFunc.Test(bz, dc.DepthBuffer[i]) {
+				if dc.StencilTest {
+					dc.stencilOp(i, dc.StencilDepthFail)
+				}
 				continue
 			}
 			// perspective-correct interpolation of vertex data
 			b := VectorW{b0 * r0, b1 * r1, b2 * r2, 0}
 			b.W = 1 / (b.X + b.Y + b.Z)
 			v := InterpolateVertexes(v0, v1, v2, b)
+			if textured {
+				tx := interpolateTexture(v0, v1, v2,
+					b0+a12*ra, b1+a20*ra, b2+a01*ra, r0, r1, r2)
+				ty := interpolateTexture(v0, v1, v2,
+					b0+b12*ra, b1+b20*ra, b2+b01*ra, r0, r1, r2)
+				v.TextureDx = tx.Sub(v.Texture)
+				v.TextureDy = ty.Sub(v.Texture)
+			}
 			// invoke fragment shader
-			color := dc.Shader.Fragment(v)
+			var color Color
+			if outputs != nil {
+				color = multi.FragmentOutputs(v, outputs)
+			} else {
+				color = dc.Shader.Fragment(v)
+			}
 			if color == Discard {
 				continue
 			}
-			// update buffers atomically
-			lock := &dc.locks[(x+y)&255]
-			lock.Lock()
-			// check depth buffer again
-			if bz <= dc.DepthBuffer[i] || !dc.ReadDepth {
-				info.UpdatedPixels++
-				if dc.WriteDepth {
-					// update depth buffer
-					dc.DepthBuffer[i] = z
-				}
+			// the pixel belongs to a single tile, so no other goroutine
+			// can be writing to it
+			info.UpdatedPixels++
+			if dc.StencilTest {
+				dc.stencilOp(i, dc.StencilPass)
+			}
+			if dc.Transparency == TransparencyOrderIndependent && dc.AlphaBlend && color.A < 1 {
+				// translucent fragments are kept for CompositeFragments
+				// and do not occlude each other
 				if dc.WriteColor {
-					// update color buffer
-					if dc.AlphaBlend && color.A < 1 {
-						sr, sg, sb, sa := color.NRGBA().RGBA()
-						a := (0xffff - sa) * 0x101
-						j := dc.ColorBuffer.PixOffset(x, y)
-						dr := &dc.ColorBuffer.Pix[j+0]
-						dg := &dc.ColorBuffer.Pix[j+1]
-						db := &dc.ColorBuffer.Pix[j+2]
-						da := &dc.ColorBuffer.Pix[j+3]
-						*dr = uint8((uint32(*dr)*a/0xffff + sr) >> 8)
-						*dg = uint8((uint32(*dg)*a/0xffff + sg) >> 8)
-						*db = uint8((uint32(*db)*a/0xffff + sb) >> 8)
-						*da = uint8((uint32(*da)*a/0xffff + sa) >> 8)
-					} else {
-						dc.ColorBuffer.SetNRGBA(x, y, color.NRGBA())
-					}
+					dc.addFragment(i, z, bz, color)
 				}
+				continue
+			}
+			if dc.WriteDepth {
+				// update depth buffer
+				dc.DepthBuffer[i] = z
+			}
+			for k, output := range outputs {
+				// update attachments
+				dc.Attachments[k].Buffer[i] = output
+			}
+			if dc.WriteID {
+				// update id buffers
+				dc.IDBuffer[i] = dc.ObjectID
+				dc.PrimitiveBuffer[i] = p.index
+			}
+			if dc.WriteColor {
+				// update color buffer
+				dc.writeColor(x, y, color, dc.blend())
 			}
-			lock.Unlock()
 		}
 		w00 += b12
 		w01 += b20
@@ -260,27 +551,28 @@ func (dc *Context) rasterize(v0, v1, v2 Vertex, s0, s1, s2 Vector) RasterizeInfo
 	return info
 }
 
-func (dc *Context) line(v0, v1 Vertex, s0, s1 Vector) RasterizeInfo {
-	n := s1.Sub(s0).Perpendicular().MulScalar(dc.LineWidth / 2)
-	s0 = s0.Add(s0.Sub(s1).Normalize().MulScalar(dc.LineWidth / 2))
-	s1 = s1.Add(s1.Sub(s0).Normalize().MulScalar(dc.LineWidth / 2))
+func (dc *Context) line(v0, v1 Vertex, s0, s1 Vector, primitives []primitive) []primitive {
+	r := dc.LineWidth * float64(dc.Scale) / 2
+	n := s1.Sub(s0).Perpendicular().MulScalar(r)
+	s0 = s0.Add(s0.Sub(s1).Normalize().MulScalar(r))
+	s1 = s1.Add(s1.Sub(s0).Normalize().MulScalar(r))
 	s00 := s0.Add(n)
 	s01 := s0.Sub(n)
 	s10 := s1.Add(n)
 	s11 := s1.Sub(n)
-	info1 := dc.rasterize(v1, v0, v0, s11, s01, s00)
-	info2 := dc.rasterize(v1, v1, v0, s10, s11, s00)
-	return info1.Add(info2)
+	primitives = appendPrimitive(primitives, primitive{v1, v0, v0, s11, s01, s00, 0})
+	primitives = appendPrimitive(primitives, primitive{v1, v1, v0, s10, s11, s00, 0})
+	return primitives
 }
 
-func (dc *Context) wireframe(v0, v1, v2 Vertex, s0, s1, s2 Vector) RasterizeInfo {
-	info1 := dc.line(v0, v1, s0, s1)
-	info2 := dc.line(v1, v2, s1, s2)
-	info3 := dc.line(v2, v0, s2, s0)
-	return info1.Add(info2).Add(info3)
+func (dc *Context) wireframe(v0, v1, v2 Vertex, s0, s1, s2 Vector, primitives []primitive) []primitive {
+	primitives = dc.line(v0, v1, s0, s1, primitives)
+	primitives = dc.line(v1, v2, s1, s2, primitives)
+	primitives = dc.line(v2, v0, s2, s0, primitives)
+	return primitives
 }
 
-func (dc *Context) drawClippedLine(v0, v1 Vertex) RasterizeInfo {
+func (dc *Context) clippedLine(v0, v1 Vertex, primitives []primitive) []primitive {
 	// normalized device coordinates
 	ndc0 := v0.Output.DivScalar(v0.Output.W).Vector()
 	ndc1 := v1.Output.DivScalar(v1.Output.W).Vector()
@@ -289,11 +581,10 @@ func (dc *Context) drawClippedLine(v0, v1 Vertex) RasterizeInfo {
 	s0 := dc.screenMatrix.MulPosition(ndc0)
 	s1 := dc.screenMatrix.MulPosition(ndc1)
 
-	// rasterize
-	return dc.line(v0, v1, s0, s1)
+	return dc.line(v0, v1, s0, s1, primitives)
 }
 
-func (dc *Context) drawClippedTriangle(v0, v1, v2 Vertex) RasterizeInfo {
+func (dc *Context) clippedTriangle(v0, v1, v2 Vertex, primitives []primitive) []primitive {
 	// normalized device coordinates
 	ndc0 := v0.Output.DivScalar(v0.Output.W).Vector()
 	ndc1 := v1.Output.DivScalar(v1.Output.W).Vector()
@@ -312,7 +603,7 @@ func (dc *Context) drawClippedTriangle(v0, v1, v2 Vertex) RasterizeInfo {
 		a = -a
 	}
 	if dc.Cull != CullNone && a <= 0 {
-		return RasterizeInfo{}
+		return primitives
 	}
 
 	// screen coordinates
@@ -320,15 +611,15 @@ func (dc *Context) drawClippedTriangle(v0, v1, v2 Vertex) RasterizeInfo {
 	s1 := dc.screenMatrix.MulPosition(ndc1)
 	s2 := dc.screenMatrix.MulPosition(ndc2)
 
-	// rasterize
 	if dc.Wireframe {
-		return dc.wireframe(v0, v1, v2, s0, s1, s2)
+		return dc.wireframe(v0, v1, v2, s0, s1, s2, primitives)
 	}
-	return dc.rasterize(v0, v1, v2, s0, s1, s2)
+	return appendPrimitive(primitives, primitive{v0, v1, v2, s0, s1, s2, 0})
 }
 
-// DrawLine :
-func (dc *Context) DrawLine(t *Line) RasterizeInfo {
+// setupLine runs the vertex shader on a line and clips it, appending the
+// primitives that cover it on screen.
+func (dc *Context) setupLine(t *Line, primitives []primitive) []primitive {
 	// invoke vertex shader
 	v1 := dc.Shader.Vertex(t.V1)
 	v2 := dc.Shader.Vertex(t.V2)
@@ -337,15 +628,16 @@ func (dc *Context) DrawLine(t *Line) RasterizeInfo {
 		// clip to viewing volume
 		line := ClipLine(NewLine(v1, v2))
 		if line != nil {
-			return dc.drawClippedLine(line.V1, line.V2)
+			return dc.clippedLine(line.V1, line.V2, primitives)
 		}
-		return RasterizeInfo{}
+		return primitives
 	}
-	return dc.drawClippedLine(v1, v2)
+	return dc.clippedLine(v1, v2, primitives)
 }
 
-// DrawTriangle :
-func (dc *Context) DrawTriangle(t *Triangle) RasterizeInfo {
+// setupTriangle runs the vertex shader on a triangle, clips and culls it,
+// appending the primitives that remain.
+func (dc *Context) setupTriangle(t *Triangle, primitives []primitive) []primitive {
 	// invoke vertex shader
 	v1 := dc.Shader.Vertex(t.V1)
 	v2 := dc.Shader.Vertex(t.V2)
@@ -354,66 +646,57 @@ func (dc *Context) DrawTriangle(t *Triangle) RasterizeInfo {
 	if v1.Outside() || v2.Outside() || v3.Outside() {
 		// clip to viewing volume
 		triangles := ClipTriangle(NewTriangle(v1, v2, v3))
-		var result RasterizeInfo
 		for _, t := range triangles {
-			info := dc.drawClippedTriangle(t.V1, t.V2, t.V3)
-			result = result.Add(info)
+			primitives = dc.clippedTriangle(t.V1, t.V2, t.V3, primitives)
 		}
-		return result
+		return primitives
 	}
 	// no need to clip
-	return dc.drawClippedTriangle(v1, v2, v3)
+	return dc.clippedTriangle(v1, v2, v3, primitives)
 }
 
-// DrawLines :
-func (dc *Context) DrawLines(lines []*Line) RasterizeInfo {
-	wn := runtime.NumCPU()
-	ch := make(chan RasterizeInfo, wn)
-	for wi := 0; wi < wn; wi++ {
-		go func(wi int) {
-			var result RasterizeInfo
-			for i, l := range lines {
-				if i%wn == wi {
-					info := dc.DrawLine(l)
-					result = result.Add(info)
-				}
-			}
-			ch <- result
-		}(wi)
-	}
+// DrawLine draws a single line on the calling goroutine.
+func (dc *Context) DrawLine(t *Line) RasterizeInfo {
+	return dc.drawPrimitives(dc.setupLine(t, nil))
+}
+
+// DrawTriangle draws a single triangle on the calling goroutine.
+func (dc *Context) DrawTriangle(t *Triangle) RasterizeInfo {
+	return dc.drawPrimitives(dc.setupTriangle(t, nil))
+}
+
+func (dc *Context) drawPrimitives(primitives []primitive) RasterizeInfo {
+	dc.allocateBuffers()
 	var result RasterizeInfo
-	for wi := 0; wi < wn; wi++ {
-		result = result.Add(<-ch)
+	bounds := image.Rect(0, 0, dc.Width, dc.Height)
+	outputs := dc.outputs()
+	for i := range primitives {
+		result = result.Add(dc.rasterize(&primitives[i], bounds, outputs))
 	}
 	return result
 }
 
+// DrawLines :
+func (dc *Context) DrawLines(lines []*Line) RasterizeInfo {
+	return dc.drawLines(lines, 0)
+}
+
+func (dc *Context) drawLines(lines []*Line, first int) RasterizeInfo {
+	return dc.draw(len(lines), first, func(i int, primitives []primitive) []primitive {
+		return dc.setupLine(lines[i], primitives)
+	})
+}
+
 // DrawTriangles :
 func (dc *Context) DrawTriangles(triangles []*Triangle) RasterizeInfo {
-	wn := runtime.NumCPU()
-	ch := make(chan RasterizeInfo, wn)
-	for wi := 0; wi < wn; wi++ {
-		go func(wi int) {
-			var result RasterizeInfo
-			for i, t := range triangles {
-				if i%wn == wi {
-					info := dc.DrawTriangle(t)
-					result = result.Add(info)
-				}
-			}
-			ch <- result
-		}(wi)
-	}
-	var result RasterizeInfo
-	for wi := 0; wi < wn; wi++ {
-		result = result.Add(<-ch)
-	}
-	return result
+	return dc.draw(len(triangles), 0, func(i int, primitives []primitive) []primitive {
+		return dc.setupTriangle(triangles[i], primitives)
+	})
 }
 
 // DrawMesh :
 func (dc *Context) DrawMesh(mesh *Mesh) RasterizeInfo {
 	info1 := dc.DrawTriangles(mesh.Triangles)
-	info2 := dc.DrawLines(mesh.Lines)
+	info2 := dc.drawLines(mesh.Lines, len(mesh.Triangles))
 	return info1.Add(info2)
 }
@@ -0,0 +1,347 @@
+package fauxgl
+
+import (
+	"math"
+	"sync"
+)
+
+// EnvironmentTexture is a texture looked up by direction rather than by
+// texture coordinates, for skyboxes, reflections and image-based lighting.
+type EnvironmentTexture interface {
+	SampleDirection(d Vector) Color
+}
+
+// LatLongTexture is an equirectangular (latitude-longitude) environment
+// texture. The top row of the image looks straight up along +Z and the
+// center of the image looks along +X. Samples wrap around horizontally but
+// are clamped at the poles, so the Texture should use WrapRepeat.
+type LatLongTexture struct {
+	Texture Texture
+}
+
+// NewLatLongTexture :
+func NewLatLongTexture(texture Texture) *LatLongTexture {
+	return &LatLongTexture{texture}
+}
+
+// LoadLatLongTexture loads an sRGB encoded equirectangular image.
+func LoadLatLongTexture(path string) (*LatLongTexture, error) {
+	texture, err := LoadTexture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewLatLongTexture(texture), nil
+}
+
+// SampleDirection :
+func (t *LatLongTexture) SampleDirection(d Vector) Color {
+	u, v := envUV(d.Normalize())
+	// keep filtering from wrapping across the poles to the opposite edge
+	margin := 0.0
+	if it, ok := t.Texture.(*ImageTexture); ok && it.Height > 0 {
+		margin = 0.5 / float64(it.Height)
+	}
+	v = Clamp(v, margin, 1-margin)
+	return t.Texture.BilinearSample(u, v)
+}
+
+// CubeTexture is a cube map environment texture made of six square faces,
+// in the order +X, -X, +Y, -Y, +Z, -Z. The faces follow the OpenGL cube map
+// layout, in which +Y is up and each face is seen from inside the cube.
+type CubeTexture struct {
+	Faces [6]Texture
+}
+
+// NewCubeTexture :
+func NewCubeTexture(faces [6]Texture) *CubeTexture {
+	return &CubeTexture{faces}
+}
+
+// LoadCubeTexture loads the six sRGB encoded faces of a cube map. Their
+// edges are clamped so that filtering does not wrap around a face.
+func LoadCubeTexture(px, nx, py, ny, pz, nz string) (*CubeTexture, error) {
+	var faces [6]Texture
+	for i, path := range []string{px, nx, py, ny, pz, nz} {
+		texture, err := LoadTexture(path)
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := texture.(*ImageTexture); ok {
+			t.Wrap = WrapClamp
+		}
+		faces[i] = texture
+	}
+	return NewCubeTexture(faces), nil
+}
+
+// SampleDirection :
+func (t *CubeTexture) SampleDirection(d Vector) Color {
+	a := d.Abs()
+	var face int
+	var sc, tc, ma float64
+	switch {
+	case a.X >= a.Y && a.X >= a.Z:
+		ma = a.X
+		if d.X > 0 {
+			face, sc, tc = 0, -d.Z, -d.Y
+		} else {
+			face, sc, tc = 1, d.Z, -d.Y
+		}
+	case a.Y >= a.Z:
+		ma = a.Y
+		if d.Y > 0 {
+			face, sc, tc = 2, d.X, d.Z
+		} else {
+			face, sc, tc = 3, d.X, -d.Z
+		}
+	default:
+		ma = a.Z
+		if d.Z > 0 {
+			face, sc, tc = 4, d.X, -d.Y
+		} else {
+			face, sc, tc = 5, -d.X, -d.Y
+		}
+	}
+	if ma == 0 {
+		return Discard
+	}
+	u := (sc/ma + 1) / 2
+	v := (tc/ma + 1) / 2
+	// t runs down the face image, while texture v runs up it
+	return t.Faces[face].BilinearSample(u, 1-v)
+}
+
+// Environment provides image-based lighting from an environment texture.
+// Matrix transforms world space directions into the texture's frame, so it
+// can be rotated, or turned for a scene with a different up axis. Intensity
+// scales all of its light.
+//
+// The first call to Irradiance, or to Radiance with a roughness above zero,
+// convolves the texture into a diffuse irradiance map and a set of
+// specular maps prefiltered for increasing roughness.
+type Environment struct {
+	Texture    EnvironmentTexture
+	Matrix     Matrix
+	Intensity  float64
+	once       sync.Once
+	irradiance *envMap
+	specular   []*envMap
+}
+
+// roughness of each prefiltered specular map; the texture itself is used
+// for roughness 0
+var envRoughness = []float64{0.25, 0.5, 0.75, 1}
+
+// NewEnvironment :
+func NewEnvironment(texture EnvironmentTexture) *Environment {
+	return &Environment{Texture: texture, Matrix: Identity(), Intensity: 1}
+}
+
+func (e *Environment) prefilter() {
+	e.once.Do(func() {
+		// average a grid of samples within each texel
+		const n = 4
+		source := newEnvMap(128, 64)
+		parallelRows(source.h, func(y int) {
+			for x := 0; x < source.w; x++ {
+				var c Color
+				for sy := 0; sy < n; sy++ {
+					for sx := 0; sx < n; sx++ {
+						u := (float64(x) + (float64(sx)+0.5)/n) / float64(source.w)
+						v := (float64(y) + (float64(sy)+0.5)/n) / float64(source.h)
+						c = c.Add(e.Texture.SampleDirection(envDirection(u, v)))
+					}
+				}
+				source.data[y*source.w+x] = c.DivScalar(n * n)
+			}
+		})
+		small := source.downsample()
+		e.irradiance = small.downsample().convolve(32, 16, 1)
+		for i, r := range envRoughness {
+			// GGX lobes of roughness r are approximated by a Phong lobe
+			a := r * r
+			exponent := math.Max(2/(a*a)-2, 0)
+			src := source
+			w, h := 64, 32
+			if i > 0 {
+				src = small
+				w, h = 32, 16
+			}
+			e.specular = append(e.specular, src.convolve(w, h, exponent))
+		}
+	})
+}
+
+// Irradiance returns the cosine weighted average radiance around the world
+// space normal n, so that a white diffuse surface reflects exactly this.
+func (e *Environment) Irradiance(n Vector) Color {
+	e.prefilter()
+	d := e.Matrix.MulDirection(n)
+	return e.irradiance.sample(d).MulScalar(e.Intensity)
+}
+
+// Radiance returns the environment's radiance along the world space
+// direction d, blurred for the given roughness in [0, 1].
+func (e *Environment) Radiance(d Vector, roughness float64) Color {
+	d = e.Matrix.MulDirection(d)
+	roughness = Clamp(roughness, 0, 1)
+	if roughness == 0 {
+		return e.Texture.SampleDirection(d).MulScalar(e.Intensity)
+	}
+	e.prefilter()
+	var c Color
+	if roughness < envRoughness[0] {
+		c0 := e.Texture.SampleDirection(d)
+		c1 := e.specular[0].sample(d)
+		c = c0.Lerp(c1, roughness/envRoughness[0])
+	} else {
+		i := 0
+		for i < len(envRoughness)-2 && roughness > envRoughness[i+1] {
+			i++
+		}
+		r0, r1 := envRoughness[i], envRoughness[i+1]
+		t := Clamp((roughness-r0)/(r1-r0), 0, 1)
+		c = e.specular[i].sample(d).Lerp(e.specular[i+1].sample(d), t)
+	}
+	return c.MulScalar(e.Intensity)
+}
+
+// DrawEnvironment fills every sample that nothing has been drawn to, as
+// shown by the depth buffer, with the environment seen through matrix, the
+// same matrix that the scene is drawn with. It can be called before or
+// after drawing opaque geometry, but must come first when blending
+// translucent geometry in primitive order.
+func (dc *Context) DrawEnvironment(e *Environment, matrix Matrix) {
+	dc.allocateBuffers()
+	unproject := matrix.Inverse().Mul(dc.screenMatrix.Inverse())
+	parallelRows(dc.Height, func(y int) {
+		for x := 0; x < dc.Width; x++ {
+			i := y*dc.Width + x
+			if dc.DepthBuffer[i] != math.MaxFloat64 {
+				continue
+			}
+			p0 := unproject.MulPositionW(Vector{float64(x) + 0.5, float64(y) + 0.5, 0})
+			p1 := unproject.MulPositionW(Vector{float64(x) + 0.5, float64(y) + 0.5, 1})
+			d := p1.DivScalar(p1.W).Vector().Sub(p0.DivScalar(p0.W).Vector())
+			c := e.Radiance(d.Normalize(), 0).Alpha(1)
+			if dc.HDR {
+				dc.HDRBuffer[i] = c
+			} else {
+				dc.ColorBuffer.SetNRGBA(x, y, dc.encode(c))
+			}
+		}
+	})
+}
+
+// envUV returns the equirectangular texture coordinates of direction d.
+func envUV(d Vector) (float64, float64) {
+	u := 0.5 + math.Atan2(d.Y, d.X)/(2*math.Pi)
+	v := 0.5 + math.Asin(Clamp(d.Z, -1, 1))/math.Pi
+	return u, v
+}
+
+// envDirection returns the direction at equirectangular texture
+// coordinates u, v.
+func envDirection(u, v float64) Vector {
+	lng := (u - 0.5) * 2 * math.Pi
+	lat := (v - 0.5) * math.Pi
+	return Vector{
+		math.Cos(lat) * math.Cos(lng),
+		math.Cos(lat) * math.Sin(lng),
+		math.Sin(lat),
+	}
+}
+
+// envMap is a small equirectangular map of linear colors, with row 0 at
+// the bottom.
+type envMap struct {
+	w, h int
+	data []Color
+}
+
+func newEnvMap(w, h int) *envMap {
+	return &envMap{w, h, make([]Color, w*h)}
+}
+
+func (m *envMap) uv(x, y int) (float64, float64) {
+	return (float64(x) + 0.5) / float64(m.w), (float64(y) + 0.5) / float64(m.h)
+}
+
+func (m *envMap) sample(d Vector) Color {
+	u, v := envUV(d)
+	x := u*float64(m.w) - 0.5
+	y := Clamp(v*float64(m.h)-0.5, 0, float64(m.h-1))
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	fx := x - float64(x0)
+	fy := y - float64(y0)
+	y1 := y0 + 1
+	if y1 >= m.h {
+		y1 = m.h - 1
+	}
+	x0 = (x0%m.w + m.w) % m.w
+	x1 := (x0 + 1) % m.w
+	c00 := m.data[y0*m.w+x0]
+	c10 := m.data[y0*m.w+x1]
+	c01 := m.data[y1*m.w+x0]
+	c11 := m.data[y1*m.w+x1]
+	return c00.Lerp(c10, fx).Lerp(c01.Lerp(c11, fx), fy)
+}
+
+// downsample halves the resolution of the map.
+func (m *envMap) downsample() *envMap {
+	r := newEnvMap(m.w/2, m.h/2)
+	for y := 0; y < r.h; y++ {
+		for x := 0; x < r.w; x++ {
+			c := m.data[(y*2)*m.w+x*2].
+				Add(m.data[(y*2)*m.w+x*2+1]).
+				Add(m.data[(y*2+1)*m.w+x*2]).
+				Add(m.data[(y*2+1)*m.w+x*2+1])
+			r.data[y*r.w+x] = c.DivScalar(4)
+		}
+	}
+	return r
+}
+
+// convolve returns a w x h map holding, for each direction, the average of
+// this map weighted by the cosine of the angle to it raised to exponent.
+// Each texel is also weighted by the solid angle it covers.
+func (m *envMap) convolve(w, h int, exponent float64) *envMap {
+	directions := make([]Vector, len(m.data))
+	areas := make([]float64, len(m.data))
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			u, v := m.uv(x, y)
+			d := envDirection(u, v)
+			directions[y*m.w+x] = d
+			areas[y*m.w+x] = math.Sqrt(1 - d.Z*d.Z)
+		}
+	}
+	// lobe values below this are ignored
+	threshold := 0.0
+	if exponent > 0 {
+		threshold = math.Exp(math.Log(1e-4) / exponent)
+	}
+	r := newEnvMap(w, h)
+	parallelRows(h, func(y int) {
+		for x := 0; x < w; x++ {
+			n := envDirection(r.uv(x, y))
+			var sum Color
+			var total float64
+			for i, d := range directions {
+				c := n.Dot(d)
+				if c <= threshold {
+					continue
+				}
+				weight := areas[i] * math.Pow(c, exponent)
+				sum = sum.Add(m.data[i].MulScalar(weight))
+				total += weight
+			}
+			if total > 0 {
+				sum = sum.DivScalar(total)
+			}
+			r.data[y*w+x] = sum
+		}
+	})
+	return r
+}
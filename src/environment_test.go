@@ -0,0 +1,22 @@
+package fauxgl
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestLatLongTexturePoles(t *testing.T) {
+	// white sky over black ground; looking straight up must not blend in
+	// the ground from the wrapped bottom row
+	im := uniformImage(8, 4, color.NRGBA{0, 0, 0, 255})
+	for x := 0; x < 8; x++ {
+		im.SetNRGBA(x, 0, color.NRGBA{255, 255, 255, 255})
+	}
+	env := NewLatLongTexture(NewImageTexture(im))
+	if got := env.SampleDirection(Vector{0, 0, 1}); !colorsEqual(got, White, 1e-9) {
+		t.Errorf("SampleDirection(up) = %v, want white", got)
+	}
+	if got := env.SampleDirection(Vector{0, 0, -1}); !colorsEqual(got, Black, 1e-9) {
+		t.Errorf("SampleDirection(down) = %v, want black", got)
+	}
+}
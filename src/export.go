@@ -0,0 +1,255 @@
+package fauxgl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveMesh writes mesh to path in the format given by its extension. PLY
+// files are written in binary; use SavePLYASCII for the text variant.
+func SaveMesh(path string, mesh *Mesh) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".stl":
+		return SaveSTL(path, mesh)
+	case ".obj":
+		return SaveOBJ(path, mesh)
+	case ".ply":
+		return SavePLY(path, mesh)
+	}
+	return fmt.Errorf("unrecognized mesh extension: %s", ext)
+}
+
+// meshIndex deduplicates the vertexes of a mesh's triangles and lines.
+type meshIndex struct {
+	vertexes []Vertex
+	faces    [][3]int
+	edges    [][2]int
+}
+
+type meshIndexKey struct {
+	Position, Normal, Texture Vector
+	Color                     Color
+}
+
+func newMeshIndex(mesh *Mesh) *meshIndex {
+	index := &meshIndex{}
+	lookup := make(map[meshIndexKey]int)
+	add := func(v Vertex) int {
+		key := meshIndexKey{v.Position, v.Normal, v.Texture, v.Color}
+		if i, ok := lookup[key]; ok {
+			return i
+		}
+		i := len(index.vertexes)
+		lookup[key] = i
+		index.vertexes = append(index.vertexes, v)
+		return i
+	}
+	index.faces = make([][3]int, len(mesh.Triangles))
+	for i, t := range mesh.Triangles {
+		index.faces[i] = [3]int{add(t.V1), add(t.V2), add(t.V3)}
+	}
+	index.edges = make([][2]int, len(mesh.Lines))
+	for i, l := range mesh.Lines {
+		index.edges[i] = [2]int{add(l.V1), add(l.V2)}
+	}
+	return index
+}
+
+// SaveOBJ writes mesh as a Wavefront OBJ file, with normals and texture
+// coordinates if any of its triangles have them. Identical positions,
+// normals and texture coordinates are written once and shared between faces.
+func SaveOBJ(path string, mesh *Mesh) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	if err := writeOBJ(w, mesh); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeOBJ(w io.Writer, mesh *Mesh) error {
+	vs := make(map[Vector]int)
+	vts := make(map[Vector]int)
+	vns := make(map[Vector]int)
+	index := func(lookup map[Vector]int, v Vector, prefix string, n int) (int, error) {
+		if i, ok := lookup[v]; ok {
+			return i, nil
+		}
+		i := len(lookup) + 1 // 1-based indexing
+		lookup[v] = i
+		var err error
+		if n == 2 {
+			_, err = fmt.Fprintf(w, "%s %g %g\n", prefix, v.X, v.Y)
+		} else {
+			_, err = fmt.Fprintf(w, "%s %g %g %g\n", prefix, v.X, v.Y, v.Z)
+		}
+		return i, err
+	}
+	var hasTexture, hasNormal bool
+	for _, t := range mesh.Triangles {
+		for _, v := range [3]Vertex{t.V1, t.V2, t.V3} {
+			hasTexture = hasTexture || v.Texture != (Vector{})
+			hasNormal = hasNormal || v.Normal != (Vector{})
+		}
+	}
+	for _, t := range mesh.Triangles {
+		var f [3]string
+		for j, v := range [3]Vertex{t.V1, t.V2, t.V3} {
+			iv, err := index(vs, v.Position, "v", 3)
+			if err != nil {
+				return err
+			}
+			f[j] = fmt.Sprint(iv)
+			if hasTexture {
+				ivt, err := index(vts, v.Texture, "vt", 2)
+				if err != nil {
+					return err
+				}
+				f[j] += fmt.Sprintf("/%d", ivt)
+			}
+			if hasNormal {
+				ivn, err := index(vns, v.Normal, "vn", 3)
+				if err != nil {
+					return err
+				}
+				if !hasTexture {
+					f[j] += "/"
+				}
+				f[j] += fmt.Sprintf("/%d", ivn)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "f %s %s %s\n", f[0], f[1], f[2]); err != nil {
+			return err
+		}
+	}
+	for _, l := range mesh.Lines {
+		i1, err := index(vs, l.V1.Position, "v", 3)
+		if err != nil {
+			return err
+		}
+		i2, err := index(vs, l.V2.Position, "v", 3)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "l %d %d\n", i1, i2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SavePLY writes mesh as a binary little endian PLY file with vertex
+// normals, texture coordinates and sRGB encoded colors. Lines are written as
+// edge elements.
+func SavePLY(path string, mesh *Mesh) error {
+	return savePLY(path, mesh, false)
+}
+
+// SavePLYASCII writes mesh as an ASCII PLY file with vertex normals, texture
+// coordinates and sRGB encoded colors. Lines are written as edge elements.
+func SavePLYASCII(path string, mesh *Mesh) error {
+	return savePLY(path, mesh, true)
+}
+
+func savePLY(path string, mesh *Mesh, ascii bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	index := newMeshIndex(mesh)
+	format := "binary_little_endian"
+	if ascii {
+		format = "ascii"
+	}
+	fmt.Fprintln(w, "ply")
+	fmt.Fprintf(w, "format %s 1.0\n", format)
+	fmt.Fprintf(w, "element vertex %d\n", len(index.vertexes))
+	for _, name := range []string{"x", "y", "z", "nx", "ny", "nz", "s", "t"} {
+		fmt.Fprintf(w, "property float %s\n", name)
+	}
+	for _, name := range []string{"red", "green", "blue", "alpha"} {
+		fmt.Fprintf(w, "property uchar %s\n", name)
+	}
+	fmt.Fprintf(w, "element face %d\n", len(index.faces))
+	fmt.Fprintln(w, "property list uchar int vertex_indices")
+	if len(index.edges) > 0 {
+		fmt.Fprintf(w, "element edge %d\n", len(index.edges))
+		fmt.Fprintln(w, "property int vertex1")
+		fmt.Fprintln(w, "property int vertex2")
+	}
+	fmt.Fprintln(w, "end_header")
+	for _, v := range index.vertexes {
+		p, n, t := v.Position, v.Normal, v.Texture
+		c := encodeNRGBA(v.Color, EncodingSRGB)
+		if ascii {
+			fmt.Fprintf(w, "%g %g %g %g %g %g %g %g %d %d %d %d\n",
+				p.X, p.Y, p.Z, n.X, n.Y, n.Z, t.X, t.Y, c.R, c.G, c.B, c.A)
+			continue
+		}
+		var b [36]byte
+		for i, f := range [8]float64{p.X, p.Y, p.Z, n.X, n.Y, n.Z, t.X, t.Y} {
+			binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(float32(f)))
+		}
+		b[32], b[33], b[34], b[35] = c.R, c.G, c.B, c.A
+		w.Write(b[:])
+	}
+	for _, f := range index.faces {
+		if ascii {
+			fmt.Fprintf(w, "3 %d %d %d\n", f[0], f[1], f[2])
+			continue
+		}
+		var b [13]byte
+		b[0] = 3
+		binary.LittleEndian.PutUint32(b[1:], uint32(f[0]))
+		binary.LittleEndian.PutUint32(b[5:], uint32(f[1]))
+		binary.LittleEndian.PutUint32(b[9:], uint32(f[2]))
+		w.Write(b[:])
+	}
+	for _, e := range index.edges {
+		if ascii {
+			fmt.Fprintf(w, "%d %d\n", e[0], e[1])
+			continue
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint32(b[0:], uint32(e[0]))
+		binary.LittleEndian.PutUint32(b[4:], uint32(e[1]))
+		w.Write(b[:])
+	}
+	return w.Flush()
+}
+
+// SaveSTLASCII writes mesh as an ASCII STL file.
+func SaveSTLASCII(path string, mesh *Mesh) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "solid fauxgl")
+	for _, t := range mesh.Triangles {
+		n := t.Normal()
+		fmt.Fprintf(w, "facet normal %g %g %g\n", n.X, n.Y, n.Z)
+		fmt.Fprintln(w, "  outer loop")
+		for _, v := range [3]Vector{t.V1.Position, t.V2.Position, t.V3.Position} {
+			fmt.Fprintf(w, "    vertex %g %g %g\n", v.X, v.Y, v.Z)
+		}
+		fmt.Fprintln(w, "  endloop")
+		fmt.Fprintln(w, "endfacet")
+	}
+	fmt.Fprintln(w, "endsolid fauxgl")
+	return w.Flush()
+}
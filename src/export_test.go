@@ -0,0 +1,175 @@
+package fauxgl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testMesh() *Mesh {
+	mesh := NewCube()
+	mesh.Transform(Translate(Vector{1, 2, 3}))
+	mesh.Lines = append(mesh.Lines,
+		NewLineForPoints(Vector{0, 0, 0}, Vector{1, 0, 0}),
+		NewLineForPoints(Vector{1, 0, 0}, Vector{1, 1, 0.5}))
+	return mesh
+}
+
+func checkTriangles(t *testing.T, got, want *Mesh) {
+	t.Helper()
+	if len(got.Triangles) != len(want.Triangles) {
+		t.Fatalf("got %d triangles, want %d", len(got.Triangles), len(want.Triangles))
+	}
+	for i, a := range got.Triangles {
+		b := want.Triangles[i]
+		for j, p := range [3]Vector{a.V1.Position, a.V2.Position, a.V3.Position} {
+			q := [3]Vector{b.V1.Position, b.V2.Position, b.V3.Position}[j]
+			if !vectorsEqual(p, q, 1e-6) {
+				t.Fatalf("triangle %d vertex %d = %v, want %v", i, j, p, q)
+			}
+		}
+	}
+}
+
+func checkLines(t *testing.T, got, want *Mesh) {
+	t.Helper()
+	if len(got.Lines) != len(want.Lines) {
+		t.Fatalf("got %d lines, want %d", len(got.Lines), len(want.Lines))
+	}
+	for i, a := range got.Lines {
+		b := want.Lines[i]
+		if !vectorsEqual(a.V1.Position, b.V1.Position, 1e-6) ||
+			!vectorsEqual(a.V2.Position, b.V2.Position, 1e-6) {
+			t.Fatalf("line %d = %v %v, want %v %v",
+				i, a.V1.Position, a.V2.Position, b.V1.Position, b.V2.Position)
+		}
+	}
+}
+
+func TestPLYRoundTrip(t *testing.T) {
+	dir := tempDir(t)
+	mesh := testMesh()
+	for name, save := range map[string]func(string, *Mesh) error{
+		"binary": SavePLY,
+		"ascii":  SavePLYASCII,
+	} {
+		path := filepath.Join(dir, name+".ply")
+		if err := save(path, mesh); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadPLY(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		checkTriangles(t, loaded, mesh)
+		checkLines(t, loaded, mesh)
+	}
+}
+
+func TestSTLRoundTrip(t *testing.T) {
+	dir := tempDir(t)
+	mesh := testMesh()
+	for name, save := range map[string]func(string, *Mesh) error{
+		"binary": SaveSTL,
+		"ascii":  SaveSTLASCII,
+	} {
+		path := filepath.Join(dir, name+".stl")
+		if err := save(path, mesh); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadSTL(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		checkTriangles(t, loaded, mesh)
+	}
+}
+
+func TestOBJRoundTrip(t *testing.T) {
+	dir := tempDir(t)
+	mesh := testMesh()
+	path := filepath.Join(dir, "mesh.obj")
+	if err := SaveMesh(path, mesh); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOBJ(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTriangles(t, loaded, mesh)
+}
+
+func TestSaveMeshUnknownExtension(t *testing.T) {
+	if err := SaveMesh(filepath.Join(tempDir(t), "mesh.xyz"), testMesh()); err == nil {
+		t.Error("SaveMesh succeeded for an unknown extension")
+	}
+}
+
+func TestSaveOBJAttributes(t *testing.T) {
+	triangle := &Triangle{}
+	triangle.V1.Position = Vector{0, 0, 0}
+	triangle.V2.Position = Vector{1, 0, 0}
+	triangle.V3.Position = Vector{0, 1, 0}
+	cases := []struct {
+		name    string
+		texture Vector
+		normal  Vector
+		face    string
+	}{
+		{"positions", Vector{}, Vector{}, "f 1 2 3\n"},
+		{"texture", Vector{0.5, 0.5, 0}, Vector{}, "f 1/1 2/1 3/1\n"},
+		{"normals", Vector{}, Vector{0, 0, 1}, "f 1//1 2//1 3//1\n"},
+		{"both", Vector{0.5, 0.5, 0}, Vector{0, 0, 1}, "f 1/1/1 2/1/1 3/1/1\n"},
+	}
+	for _, c := range cases {
+		triangle.V1.Texture = c.texture
+		triangle.V2.Texture = c.texture
+		triangle.V3.Texture = c.texture
+		triangle.V1.Normal = c.normal
+		triangle.V2.Normal = c.normal
+		triangle.V3.Normal = c.normal
+		var buf bytes.Buffer
+		if err := writeOBJ(&buf, NewTriangleMesh([]*Triangle{triangle})); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, c.face) {
+			t.Errorf("%s: faces written as\n%s", c.name, out)
+		}
+		if hasVT := strings.Contains(out, "vt "); hasVT != (c.texture != Vector{}) {
+			t.Errorf("%s: vt lines written = %v", c.name, hasVT)
+		}
+		if hasVN := strings.Contains(out, "vn "); hasVN != (c.normal != Vector{}) {
+			t.Errorf("%s: vn lines written = %v", c.name, hasVN)
+		}
+	}
+}
+
+func TestLoadPLYMalformedEdges(t *testing.T) {
+	dir := tempDir(t)
+	header := "ply\nformat %s 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\n" +
+		"element edge 1\nproperty int vertex1\nproperty int vertex2\nend_header\n"
+	ascii := strings.Replace(header, "%s", "ascii", 1) + "0 0 0\n1 0 0\n"
+	files := map[string]string{
+		"out of range": ascii + "0 2\n",
+		"negative":     ascii + "-1 0\n",
+		"garbled":      ascii + "0 x\n",
+	}
+	var buf bytes.Buffer
+	buf.WriteString(strings.Replace(header, "%s", "binary_little_endian", 1))
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, []int32{0, 5})
+	files["binary out of range"] = buf.String()
+	for name, data := range files {
+		path := filepath.Join(dir, "edges.ply")
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPLY(path); err == nil {
+			t.Errorf("%s: LoadPLY succeeded", name)
+		}
+	}
+}
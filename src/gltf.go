@@ -0,0 +1,701 @@
+package fauxgl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+type gltfDocument struct {
+	Scene       *int             `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Matrix      []float64 `json:"matrix"`
+	Translation []float64 `json:"translation"`
+	Rotation    []float64 `json:"rotation"`
+	Scale       []float64 `json:"scale"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfMaterial struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          []float64       `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureRef `json:"baseColorTexture"`
+		MetallicFactor           *float64        `json:"metallicFactor"`
+		RoughnessFactor          *float64        `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureRef `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *gltfTextureRef `json:"normalTexture"`
+	OcclusionTexture *gltfTextureRef `json:"occlusionTexture"`
+	EmissiveFactor   []float64       `json:"emissiveFactor"`
+	EmissiveTexture  *gltfTextureRef `json:"emissiveTexture"`
+}
+
+type gltfTextureRef struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source *int `json:"source"`
+}
+
+type gltfImage struct {
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+}
+
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+var gltfComponentSizes = map[int]int{
+	gltfByte:          1,
+	gltfUnsignedByte:  1,
+	gltfShort:         2,
+	gltfUnsignedShort: 2,
+	gltfUnsignedInt:   4,
+	gltfFloat:         4,
+}
+
+var gltfTypeSizes = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+const (
+	gltfModeLines         = 1
+	gltfModeTriangles     = 4
+	gltfModeTriangleStrip = 5
+	gltfModeTriangleFan   = 6
+)
+
+type gltfLoader struct {
+	dir          string
+	doc          gltfDocument
+	buffers      [][]byte
+	materials    map[int]*Material
+	textures     map[gltfTextureKey]Texture
+	loadTextures bool
+}
+
+type gltfTextureKey struct {
+	index int
+	srgb  bool
+}
+
+// LoadGLTF loads a .gltf or .glb file and flattens every mesh in its default
+// scene into a single mesh, with node transforms applied. Base color factors
+// are baked into the vertex colors; images are not loaded.
+func LoadGLTF(path string) (*Mesh, error) {
+	root, err := loadGLTF(path, false)
+	if err != nil {
+		return nil, err
+	}
+	mesh := NewEmptyMesh()
+	root.Walk(func(node *Node, matrix Matrix) {
+		if node.Mesh == nil {
+			return
+		}
+		m := node.Mesh.Copy()
+		if node.Material != nil {
+			for _, t := range m.Triangles {
+				t.V1.Color = t.V1.Color.Mul(node.Material.Color)
+				t.V2.Color = t.V2.Color.Mul(node.Material.Color)
+				t.V3.Color = t.V3.Color.Mul(node.Material.Color)
+			}
+			for _, l := range m.Lines {
+				l.V1.Color = l.V1.Color.Mul(node.Material.Color)
+				l.V2.Color = l.V2.Color.Mul(node.Material.Color)
+			}
+		}
+		m.Transform(matrix)
+		mesh.Add(m)
+	})
+	return mesh, nil
+}
+
+// LoadGLTFScene loads a .gltf or .glb file and returns the node hierarchy of
+// its default scene. Each mesh primitive becomes a node holding its Mesh and
+// Material, attached under the glTF node that references it. Images that
+// fail to load, such as missing files or unsupported formats, leave their
+// textures unset and are recorded in the material's Errors.
+func LoadGLTFScene(path string) (*Node, error) {
+	return loadGLTF(path, true)
+}
+
+func loadGLTF(path string, loadTextures bool) (*Node, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &gltfLoader{
+		dir:          filepath.Dir(path),
+		materials:    make(map[int]*Material),
+		textures:     make(map[gltfTextureKey]Texture),
+		loadTextures: loadTextures,
+	}
+	var bin []byte
+	if len(data) >= 12 && string(data[:4]) == "glTF" {
+		data, bin, err = readGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return nil, err
+	}
+	if err := l.loadBuffers(bin); err != nil {
+		return nil, err
+	}
+	return l.scene()
+}
+
+func readGLB(data []byte) ([]byte, []byte, error) {
+	version := binary.LittleEndian.Uint32(data[4:8])
+	if version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version: %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:12]))
+	if length > len(data) {
+		return nil, nil, errors.New("truncated glb file")
+	}
+	var doc, bin []byte
+	for i := 12; i+8 <= length; {
+		size := int(binary.LittleEndian.Uint32(data[i : i+4]))
+		kind := binary.LittleEndian.Uint32(data[i+4 : i+8])
+		i += 8
+		if i+size > length {
+			return nil, nil, errors.New("truncated glb chunk")
+		}
+		switch kind {
+		case 0x4E4F534A: // JSON
+			doc = data[i : i+size]
+		case 0x004E4942: // BIN
+			bin = data[i : i+size]
+		}
+		i += size
+	}
+	if doc == nil {
+		return nil, nil, errors.New("glb file has no json chunk")
+	}
+	return doc, bin, nil
+}
+
+func (l *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.Index(uri, ";base64,")
+		if i < 0 {
+			return nil, errors.New("unsupported gltf data uri")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+8:])
+	}
+	return ioutil.ReadFile(filepath.Join(l.dir, filepath.FromSlash(uri)))
+}
+
+func (l *gltfLoader) loadBuffers(bin []byte) error {
+	l.buffers = make([][]byte, len(l.doc.Buffers))
+	for i, b := range l.doc.Buffers {
+		if b.URI == "" {
+			// the first buffer of a glb file refers to its binary chunk
+			l.buffers[i] = bin
+			continue
+		}
+		data, err := l.readURI(b.URI)
+		if err != nil {
+			return err
+		}
+		l.buffers[i] = data
+	}
+	return nil
+}
+
+func (l *gltfLoader) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(l.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("invalid gltf buffer view: %d", index)
+	}
+	v := l.doc.BufferViews[index]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, fmt.Errorf("invalid gltf buffer: %d", v.Buffer)
+	}
+	buffer := l.buffers[v.Buffer]
+	if v.ByteOffset+v.ByteLength > len(buffer) {
+		return nil, 0, errors.New("gltf buffer view out of range")
+	}
+	return buffer[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// accessor returns the accessor's elements as float64s, count*n values long,
+// where n is the number of components per element.
+func (l *gltfLoader) accessor(index int) ([]float64, int, error) {
+	if index < 0 || index >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("invalid gltf accessor: %d", index)
+	}
+	a := l.doc.Accessors[index]
+	n, ok := gltfTypeSizes[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported gltf accessor type: %s", a.Type)
+	}
+	size, ok := gltfComponentSizes[a.ComponentType]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported gltf component type: %d", a.ComponentType)
+	}
+	result := make([]float64, a.Count*n)
+	if a.BufferView == nil {
+		return result, n, nil
+	}
+	data, stride, err := l.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	if stride == 0 {
+		stride = size * n
+	}
+	if a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+size*n > len(data) {
+		return nil, 0, errors.New("gltf accessor out of range")
+	}
+	for i := 0; i < a.Count; i++ {
+		for j := 0; j < n; j++ {
+			b := data[a.ByteOffset+i*stride+j*size:]
+			result[i*n+j] = gltfComponent(b, a.ComponentType, a.Normalized)
+		}
+	}
+	return result, n, nil
+}
+
+func gltfComponent(b []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		x := float64(int8(b[0]))
+		if normalized {
+			return math.Max(x/127, -1)
+		}
+		return x
+	case gltfUnsignedByte:
+		x := float64(b[0])
+		if normalized {
+			return x / 255
+		}
+		return x
+	case gltfShort:
+		x := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(x/32767, -1)
+		}
+		return x
+	case gltfUnsignedShort:
+		x := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return x / 65535
+		}
+		return x
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	case gltfFloat:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	return 0
+}
+
+func (l *gltfLoader) scene() (*Node, error) {
+	root := CreateNode("", nil, Discard)
+	var nodes []int
+	if len(l.doc.Scenes) > 0 {
+		index := 0
+		if l.doc.Scene != nil {
+			index = *l.doc.Scene
+		}
+		if index < 0 || index >= len(l.doc.Scenes) {
+			return nil, fmt.Errorf("invalid gltf scene: %d", index)
+		}
+		root.Name = l.doc.Scenes[index].Name
+		nodes = l.doc.Scenes[index].Nodes
+	} else {
+		// no scenes; use every node that is not a child of another
+		child := make(map[int]bool)
+		for _, n := range l.doc.Nodes {
+			for _, c := range n.Children {
+				child[c] = true
+			}
+		}
+		for i := range l.doc.Nodes {
+			if !child[i] {
+				nodes = append(nodes, i)
+			}
+		}
+	}
+	visited := make(map[int]bool)
+	for _, i := range nodes {
+		node, err := l.node(i, visited)
+		if err != nil {
+			return nil, err
+		}
+		root.AddChild(node)
+	}
+	return root, nil
+}
+
+func (l *gltfLoader) node(index int, visited map[int]bool) (*Node, error) {
+	if index < 0 || index >= len(l.doc.Nodes) {
+		return nil, fmt.Errorf("invalid gltf node: %d", index)
+	}
+	if visited[index] {
+		return nil, fmt.Errorf("gltf node %d has multiple parents", index)
+	}
+	visited[index] = true
+	n := l.doc.Nodes[index]
+	node := CreateNode(n.Name, nil, Discard)
+	node.Matrix = gltfNodeMatrix(n)
+	if n.Mesh != nil {
+		if *n.Mesh < 0 || *n.Mesh >= len(l.doc.Meshes) {
+			return nil, fmt.Errorf("invalid gltf mesh: %d", *n.Mesh)
+		}
+		m := l.doc.Meshes[*n.Mesh]
+		for _, p := range m.Primitives {
+			child, err := l.primitive(m.Name, p)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				node.AddChild(child)
+			}
+		}
+	}
+	for _, c := range n.Children {
+		child, err := l.node(c, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.AddChild(child)
+	}
+	return node, nil
+}
+
+func gltfNodeMatrix(n gltfNode) Matrix {
+	if len(n.Matrix) == 16 {
+		// column-major
+		m := n.Matrix
+		return Matrix{
+			m[0], m[4], m[8], m[12],
+			m[1], m[5], m[9], m[13],
+			m[2], m[6], m[10], m[14],
+			m[3], m[7], m[11], m[15],
+		}
+	}
+	matrix := Identity()
+	if len(n.Scale) == 3 {
+		matrix = matrix.Scale(Vector{n.Scale[0], n.Scale[1], n.Scale[2]})
+	}
+	if len(n.Rotation) == 4 {
+		r := n.Rotation
+		matrix = gltfQuaternion(r[0], r[1], r[2], r[3]).Mul(matrix)
+	}
+	if len(n.Translation) == 3 {
+		matrix = matrix.Translate(Vector{n.Translation[0], n.Translation[1], n.Translation[2]})
+	}
+	return matrix
+}
+
+func gltfQuaternion(x, y, z, w float64) Matrix {
+	return Matrix{
+		1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0,
+		2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0,
+		2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
+func (l *gltfLoader) primitive(name string, p gltfPrimitive) (*Node, error) {
+	mode := gltfModeTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	position, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, nil
+	}
+	positions, _, err := l.accessor(position)
+	if err != nil {
+		return nil, err
+	}
+	count := len(positions) / 3
+	vertexes := make([]Vertex, count)
+	for i := range vertexes {
+		vertexes[i].Position = Vector{positions[i*3], positions[i*3+1], positions[i*3+2]}
+		vertexes[i].Color = White
+	}
+	if a, ok := p.Attributes["NORMAL"]; ok {
+		normals, _, err := l.accessor(a)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count && i*3+2 < len(normals); i++ {
+			vertexes[i].Normal = Vector{normals[i*3], normals[i*3+1], normals[i*3+2]}
+		}
+	}
+	_, hasTangents := p.Attributes["TANGENT"]
+	if a, ok := p.Attributes["TANGENT"]; ok {
+		tangents, _, err := l.accessor(a)
+		if err != nil {
+			return nil, err
+		}
+		// gltf tangents are generated with the texture origin at the bottom
+		// left, matching ours
+		for i := 0; i < count && i*4+3 < len(tangents); i++ {
+			vertexes[i].Tangent = VectorW{tangents[i*4], tangents[i*4+1], tangents[i*4+2], tangents[i*4+3]}
+		}
+	}
+	if a, ok := p.Attributes["TEXCOORD_0"]; ok {
+		uvs, _, err := l.accessor(a)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count && i*2+1 < len(uvs); i++ {
+			// gltf has its texture origin at the top left
+			vertexes[i].Texture = Vector{uvs[i*2], 1 - uvs[i*2+1], 0}
+		}
+	}
+	if a, ok := p.Attributes["COLOR_0"]; ok {
+		colors, n, err := l.accessor(a)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count && i*n+n-1 < len(colors); i++ {
+			c := Color{colors[i*n], colors[i*n+1], colors[i*n+2], 1}
+			if n == 4 {
+				c.A = colors[i*n+3]
+			}
+			vertexes[i].Color = c
+		}
+	}
+	var indices []int
+	if p.Indices != nil {
+		values, _, err := l.accessor(*p.Indices)
+		if err != nil {
+			return nil, err
+		}
+		indices = make([]int, len(values))
+		for i, v := range values {
+			indices[i] = int(v)
+			if indices[i] < 0 || indices[i] >= count {
+				return nil, fmt.Errorf("gltf index out of range: %d", indices[i])
+			}
+		}
+	} else {
+		indices = make([]int, count)
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+	var triangles []*Triangle
+	var lines []*Line
+	triangle := func(i1, i2, i3 int) {
+		t := Triangle{vertexes[i1], vertexes[i2], vertexes[i3]}
+		t.FixNormals()
+		triangles = append(triangles, &t)
+	}
+	switch mode {
+	case gltfModeTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			triangle(indices[i], indices[i+1], indices[i+2])
+		}
+	case gltfModeTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				triangle(indices[i], indices[i+1], indices[i+2])
+			} else {
+				triangle(indices[i+1], indices[i], indices[i+2])
+			}
+		}
+	case gltfModeTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			triangle(indices[0], indices[i], indices[i+1])
+		}
+	case gltfModeLines:
+		for i := 0; i+1 < len(indices); i += 2 {
+			lines = append(lines, NewLine(vertexes[indices[i]], vertexes[indices[i+1]]))
+		}
+	default:
+		// points, line loops and line strips are not supported
+		return nil, nil
+	}
+	node := CreateNode(name, NewMesh(triangles, lines), Discard)
+	if p.Material != nil {
+		material, err := l.material(*p.Material)
+		if err != nil {
+			return nil, err
+		}
+		node.Material = material
+		if material.NormalTexture != nil && !hasTangents {
+			node.Mesh.ComputeTangents()
+		}
+	}
+	return node, nil
+}
+
+func (l *gltfLoader) material(index int) (*Material, error) {
+	if material, ok := l.materials[index]; ok {
+		return material, nil
+	}
+	if index < 0 || index >= len(l.doc.Materials) {
+		return nil, fmt.Errorf("invalid gltf material: %d", index)
+	}
+	m := l.doc.Materials[index]
+	material := NewMaterial(m.Name, White)
+	material.Shading = ShadingPBR
+	material.Metallic = 1
+	material.Roughness = 1
+	if f := m.EmissiveFactor; len(f) == 3 {
+		material.Emissive = Color{f[0], f[1], f[2], 1}
+	}
+	if pbr := m.PBRMetallicRoughness; pbr != nil {
+		if pbr.MetallicFactor != nil {
+			material.Metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			material.Roughness = *pbr.RoughnessFactor
+		}
+		if f := pbr.BaseColorFactor; len(f) == 4 {
+			material.Color = Color{f[0], f[1], f[2], f[3]}
+		}
+		if pbr.BaseColorTexture != nil {
+			material.Texture = l.materialTexture(material, pbr.BaseColorTexture.Index, true)
+		}
+		if pbr.MetallicRoughnessTexture != nil {
+			material.MetallicRoughnessTexture = l.materialTexture(material, pbr.MetallicRoughnessTexture.Index, false)
+		}
+	}
+	if m.NormalTexture != nil {
+		material.NormalTexture = l.materialTexture(material, m.NormalTexture.Index, false)
+	}
+	if m.OcclusionTexture != nil {
+		material.OcclusionTexture = l.materialTexture(material, m.OcclusionTexture.Index, false)
+	}
+	if m.EmissiveTexture != nil {
+		material.EmissiveTexture = l.materialTexture(material, m.EmissiveTexture.Index, true)
+	}
+	l.materials[index] = material
+	return material, nil
+}
+
+// materialTexture loads a texture for material, or records why it could not
+// be loaded in the material's Errors.
+func (l *gltfLoader) materialTexture(material *Material, index int, srgb bool) Texture {
+	if !l.loadTextures {
+		return nil
+	}
+	texture, err := l.texture(index, srgb)
+	if err != nil {
+		material.Errors = append(material.Errors, err)
+		return nil
+	}
+	return texture
+}
+
+func (l *gltfLoader) texture(index int, srgb bool) (Texture, error) {
+	key := gltfTextureKey{index, srgb}
+	if texture, ok := l.textures[key]; ok {
+		return texture, nil
+	}
+	if index < 0 || index >= len(l.doc.Textures) {
+		return nil, fmt.Errorf("invalid gltf texture: %d", index)
+	}
+	source := l.doc.Textures[index].Source
+	if source == nil {
+		return nil, nil
+	}
+	if *source < 0 || *source >= len(l.doc.Images) {
+		return nil, fmt.Errorf("invalid gltf image: %d", *source)
+	}
+	im := l.doc.Images[*source]
+	var data []byte
+	var err error
+	if im.BufferView != nil {
+		data, _, err = l.bufferView(*im.BufferView)
+	} else {
+		data, err = l.readURI(im.URI)
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gltf image %d: %v", *source, err)
+	}
+	var texture Texture
+	if srgb {
+		texture = NewImageTexture(decoded)
+	} else {
+		texture = NewLinearImageTexture(decoded)
+	}
+	l.textures[key] = texture
+	return texture, nil
+}
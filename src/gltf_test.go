@@ -0,0 +1,160 @@
+package fauxgl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// testGLTF returns a glTF document with one triangle, in a mesh node that is
+// nested in a node with the given transform properties.
+func testGLTF(transform string) string {
+	var buf bytes.Buffer
+	for _, f := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"scene": 0,
+	"scenes": [{"nodes": [0]}],
+	"nodes": [
+		{"name": "parent", "children": [1], %s},
+		{"name": "child", "mesh": 0, "translation": [1, 0, 0]}
+	],
+	"meshes": [{"name": "triangle", "primitives": [{"attributes": {"POSITION": 0}}]}],
+	"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36}],
+	"buffers": [{"byteLength": 36, "uri": %q}]
+}`, transform, uri)
+}
+
+func TestLoadGLTFTransforms(t *testing.T) {
+	s := math.Sqrt(0.5)
+	cases := []struct {
+		name      string
+		transform string
+		want      [3]Vector
+	}{
+		{
+			// scaled by 2, then turned 90 degrees about Z, then translated
+			"trs",
+			fmt.Sprintf(`"translation": [1, 2, 3], "rotation": [0, 0, %g, %g], "scale": [2, 2, 2]`, s, s),
+			[3]Vector{{1, 4, 3}, {1, 6, 3}, {-1, 4, 3}},
+		},
+		{
+			"matrix",
+			`"matrix": [1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 5, 6, 7, 1]`,
+			[3]Vector{{6, 6, 7}, {7, 6, 7}, {6, 7, 7}},
+		},
+	}
+	dir := tempDir(t)
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name+".gltf")
+		writeFile(t, path, testGLTF(c.transform))
+		mesh, err := LoadGLTF(path)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(mesh.Triangles) != 1 {
+			t.Fatalf("%s: got %d triangles, want 1", c.name, len(mesh.Triangles))
+		}
+		tri := mesh.Triangles[0]
+		for i, p := range [3]Vector{tri.V1.Position, tri.V2.Position, tri.V3.Position} {
+			if !vectorsEqual(p, c.want[i], 1e-6) {
+				t.Errorf("%s: vertex %d = %v, want %v", c.name, i, p, c.want[i])
+			}
+		}
+
+		root, err := LoadGLTFScene(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node := root.FindPath("parent/child/triangle")
+		if node == nil || node.Mesh == nil {
+			t.Fatalf("%s: mesh node not found", c.name)
+		}
+		if p := node.WorldMatrix().MulPosition(Vector{}); !vectorsEqual(p, c.want[0], 1e-6) {
+			t.Errorf("%s: world origin = %v, want %v", c.name, p, c.want[0])
+		}
+	}
+}
+
+// testGLTFMaterial returns a glTF document with one triangle and one line,
+// in a red material whose base color texture is the image at uri.
+func testGLTFMaterial(uri string) string {
+	var buf bytes.Buffer
+	for _, f := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.Write(&buf, binary.LittleEndian, f)
+	}
+	data := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return fmt.Sprintf(`{
+	"asset": {"version": "2.0"},
+	"scenes": [{"nodes": [0]}],
+	"nodes": [{"mesh": 0}],
+	"meshes": [{"primitives": [
+		{"attributes": {"POSITION": 0}, "material": 0},
+		{"attributes": {"POSITION": 0}, "material": 0, "mode": 1}
+	]}],
+	"materials": [{"pbrMetallicRoughness": {
+		"baseColorFactor": [1, 0, 0, 1],
+		"baseColorTexture": {"index": 0}
+	}}],
+	"textures": [{"source": 0}],
+	"images": [{"uri": %q}],
+	"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36}],
+	"buffers": [{"byteLength": 36, "uri": %q}]
+}`, uri, data)
+}
+
+func TestLoadGLTFMaterial(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "material.gltf")
+	writeFile(t, path, testGLTFMaterial("missing.png"))
+
+	// the flattened mesh does not load images, and bakes in the base color
+	mesh, err := LoadGLTF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesh.Triangles) != 1 || len(mesh.Lines) != 1 {
+		t.Fatalf("got %d triangles and %d lines, want 1 and 1", len(mesh.Triangles), len(mesh.Lines))
+	}
+	red := Color{1, 0, 0, 1}
+	if c := mesh.Triangles[0].V1.Color; c != red {
+		t.Errorf("triangle color = %v, want %v", c, red)
+	}
+	if c := mesh.Lines[0].V2.Color; c != red {
+		t.Errorf("line color = %v, want %v", c, red)
+	}
+
+	// the scene reports the missing image without failing the load
+	root, err := LoadGLTFScene(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var material *Material
+	root.Walk(func(node *Node, matrix Matrix) {
+		if node.Material != nil {
+			material = node.Material
+		}
+	})
+	if material == nil {
+		t.Fatal("material not found")
+	}
+	if material.Texture != nil || len(material.Errors) != 1 {
+		t.Errorf("missing image: texture %v, errors %v", material.Texture, material.Errors)
+	}
+
+	// images in formats without a registered decoder are reported too
+	writeFile(t, filepath.Join(dir, "image.webp"), "RIFF\x00\x00\x00\x00WEBP")
+	writeFile(t, path, testGLTFMaterial("image.webp"))
+	if _, err := LoadGLTFScene(path); err != nil {
+		t.Errorf("unsupported image failed the load: %v", err)
+	}
+}
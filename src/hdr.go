@@ -0,0 +1,192 @@
+package fauxgl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// ToneMap selects the operator used to map HDR colors into [0, 1].
+type ToneMap int
+
+// ToneMaps :
+const (
+	_ ToneMap = iota
+	ToneMapClamp
+	ToneMapReinhard
+	ToneMapACES
+)
+
+// Apply maps a linear HDR value into [0, 1].
+func (t ToneMap) Apply(x float64) float64 {
+	switch t {
+	case ToneMapReinhard:
+		x = x / (1 + x)
+	case ToneMapACES:
+		// Narkowicz's fit of the ACES filmic curve
+		x = (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+	}
+	return Clamp(x, 0, 1)
+}
+
+// hdrColor returns the straight alpha HDR color at index i, scaled by the
+// exposure.
+func (dc *Context) hdrColor(i int) Color {
+	c := dc.HDRBuffer[i]
+	if c.A <= 0 {
+		return Color{}
+	}
+	s := math.Pow(2, dc.Exposure) / c.A
+	return Color{c.R * s, c.G * s, c.B * s, c.A}
+}
+
+// toneMap writes the HDR buffer into the color buffer, applying exposure,
+// the tone mapping operator and the output encoding.
+func (dc *Context) toneMap() {
+	for y := 0; y < dc.Height; y++ {
+		for x := 0; x < dc.Width; x++ {
+			c := dc.hdrColor(y*dc.Width + x)
+			c.R = dc.ToneMap.Apply(c.R)
+			c.G = dc.ToneMap.Apply(c.G)
+			c.B = dc.ToneMap.Apply(c.B)
+			dc.ColorBuffer.SetNRGBA(x, y, dc.encode(c))
+		}
+	}
+}
+
+// SaveHDR writes the HDR buffer as a Radiance RGBE (.hdr) file. The data is
+// linear and scaled by the exposure, but not tone mapped. Supersampled
+// contexts are box filtered down to the output resolution. Pending
+// translucent fragments are composited first, as by Image.
+func (dc *Context) SaveHDR(path string) error {
+	if dc.HDRBuffer == nil {
+		return fmt.Errorf("context has no HDR buffer")
+	}
+	dc.CompositeFragments()
+	pixels := make([]Color, len(dc.HDRBuffer))
+	for i := range pixels {
+		pixels[i] = dc.hdrColor(i)
+	}
+	return saveHDR(path, pixels, dc.Width, dc.Height, dc.Scale)
+}
+
+// saveHDR box filters a buffer of linear colors sampled scale times per
+// output pixel in each dimension and writes it as a Radiance RGBE file.
+func saveHDR(path string, pixels []Color, width, height, scale int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	pixels, width, height = downsample(pixels, width, height, scale)
+	if err := writeHDR(w, width, height, pixels); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// downsample averages each scale x scale block of a buffer of colors.
+func downsample(pixels []Color, width, height, scale int) ([]Color, int, int) {
+	if scale <= 1 {
+		return pixels, width, height
+	}
+	w := width / scale
+	h := height / scale
+	result := make([]Color, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var c Color
+			for sy := 0; sy < scale; sy++ {
+				for sx := 0; sx < scale; sx++ {
+					c = c.Add(pixels[(y*scale+sy)*width+x*scale+sx])
+				}
+			}
+			result[y*w+x] = c.DivScalar(float64(scale * scale))
+		}
+	}
+	return result, w, h
+}
+
+func writeHDR(w io.Writer, width, height int, pixels []Color) error {
+	_, err := fmt.Fprintf(w, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", height, width)
+	if err != nil {
+		return err
+	}
+	rle := width >= 8 && width < 0x8000
+	line := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			copy(line[x*4:], rgbe(pixels[y*width+x]))
+		}
+		if !rle {
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+			continue
+		}
+		// adaptive run length encoding, one channel at a time
+		buf := []byte{2, 2, byte(width >> 8), byte(width)}
+		channel := make([]byte, width)
+		for c := 0; c < 4; c++ {
+			for x := 0; x < width; x++ {
+				channel[x] = line[x*4+c]
+			}
+			buf = appendRLE(buf, channel)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendRLE(buf, data []byte) []byte {
+	const minRun = 4
+	i := 0
+	for i < len(data) {
+		// find the next run of at least minRun equal bytes
+		j := i
+		run := 1
+		for j < len(data) {
+			run = 1
+			for j+run < len(data) && run < 127 && data[j+run] == data[j] {
+				run++
+			}
+			if run >= minRun {
+				break
+			}
+			j++
+		}
+		// literal bytes before the run
+		for i < j {
+			n := j - i
+			if n > 128 {
+				n = 128
+			}
+			buf = append(buf, byte(n))
+			buf = append(buf, data[i:i+n]...)
+			i += n
+		}
+		if j < len(data) {
+			buf = append(buf, byte(128+run), data[j])
+			i = j + run
+		}
+	}
+	return buf
+}
+
+func rgbe(c Color) []byte {
+	r := math.Max(c.R, 0)
+	g := math.Max(c.G, 0)
+	b := math.Max(c.B, 0)
+	v := math.Max(r, math.Max(g, b))
+	if v < 1e-32 {
+		return []byte{0, 0, 0, 0}
+	}
+	m, e := math.Frexp(v)
+	s := m * 256 / v
+	return []byte{byte(r * s), byte(g * s), byte(b * s), byte(e + 128)}
+}
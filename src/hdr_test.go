@@ -0,0 +1,132 @@
+package fauxgl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+)
+
+// readHDR decodes the pixels of a Radiance RGBE file written by writeHDR.
+func readHDR(r io.Reader) (width, height int, pixels [][]byte, err error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if line == "\n" {
+			break
+		}
+	}
+	if _, err := fmt.Fscanf(br, "-Y %d +X %d\n", &height, &width); err != nil {
+		return 0, 0, nil, err
+	}
+	for y := 0; y < height; y++ {
+		head := make([]byte, 4)
+		if _, err := io.ReadFull(br, head); err != nil {
+			return 0, 0, nil, err
+		}
+		if head[0] != 2 || head[1] != 2 {
+			// flat scanline
+			pixels = append(pixels, head)
+			for x := 1; x < width; x++ {
+				p := make([]byte, 4)
+				if _, err := io.ReadFull(br, p); err != nil {
+					return 0, 0, nil, err
+				}
+				pixels = append(pixels, p)
+			}
+			continue
+		}
+		if int(head[2])<<8|int(head[3]) != width {
+			return 0, 0, nil, fmt.Errorf("scanline %d has width %d", y, int(head[2])<<8|int(head[3]))
+		}
+		line := make([]byte, width*4)
+		for c := 0; c < 4; c++ {
+			for x := 0; x < width; {
+				n, err := br.ReadByte()
+				if err != nil {
+					return 0, 0, nil, err
+				}
+				if n > 128 {
+					v, err := br.ReadByte()
+					if err != nil {
+						return 0, 0, nil, err
+					}
+					for i := 0; i < int(n)-128; i++ {
+						line[(x+i)*4+c] = v
+					}
+					x += int(n) - 128
+				} else {
+					for i := 0; i < int(n); i++ {
+						v, err := br.ReadByte()
+						if err != nil {
+							return 0, 0, nil, err
+						}
+						line[(x+i)*4+c] = v
+					}
+					x += int(n)
+				}
+				if x > width {
+					return 0, 0, nil, fmt.Errorf("scanline %d overruns its width", y)
+				}
+			}
+		}
+		for x := 0; x < width; x++ {
+			pixels = append(pixels, line[x*4:x*4+4])
+		}
+	}
+	return width, height, pixels, nil
+}
+
+func TestWriteHDRRoundTrip(t *testing.T) {
+	// widths below 8 are written flat, the rest run length encoded; the
+	// rows mix long runs, long literals and short runs
+	for _, width := range []int{5, 8, 300} {
+		height := 3
+		pixels := make([]Color, width*height)
+		for i := range pixels {
+			x := i % width
+			switch i / width {
+			case 0:
+				pixels[i] = Color{2.5, 0.5, 0.25, 1}
+			case 1:
+				pixels[i] = Color{float64(x) / 7, float64(x%5) * 3, 0.001 * float64(x), 1}
+			default:
+				pixels[i] = Color{float64(x / 3), 0, -1, 1}
+			}
+		}
+		var buf bytes.Buffer
+		if err := writeHDR(&buf, width, height, pixels); err != nil {
+			t.Fatal(err)
+		}
+		w, h, got, err := readHDR(&buf)
+		if err != nil {
+			t.Fatalf("width %d: %v", width, err)
+		}
+		if w != width || h != height || len(got) != len(pixels) {
+			t.Fatalf("width %d: read %dx%d, %d pixels", width, w, h, len(got))
+		}
+		for i, p := range pixels {
+			if !bytes.Equal(got[i], rgbe(p)) {
+				t.Fatalf("width %d: pixel %d = %v, want %v", width, i, got[i], rgbe(p))
+			}
+			// decode the shared exponent and compare with the input
+			e := got[i]
+			s := 0.0
+			if e[3] != 0 {
+				s = math.Ldexp(1, int(e[3])-128-8)
+			}
+			want := Color{math.Max(p.R, 0), math.Max(p.G, 0), math.Max(p.B, 0), 1}
+			v := math.Max(want.R, math.Max(want.G, want.B))
+			for c, x := range []float64{want.R, want.G, want.B} {
+				if d := math.Abs(float64(e[c])*s - x); d > v/128+1e-9 {
+					t.Fatalf("width %d: pixel %d channel %d = %g, want %g", width, i, c, float64(e[c])*s, x)
+				}
+			}
+		}
+	}
+}
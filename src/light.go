@@ -0,0 +1,111 @@
+package fauxgl
+
+import "math"
+
+// LightType :
+type LightType int
+
+// LightTypes :
+const (
+	_ LightType = iota
+	LightDirectional
+	LightPoint
+	LightSpot
+)
+
+// Light describes a directional, point or spot light source.
+//
+// For directional lights, Direction points towards the light, as with
+// PhongShader.LightDirection. For spot lights, Direction is the axis the
+// spot is aimed along. InnerAngle and OuterAngle are the spot cone
+// half-angles in degrees; intensity falls off smoothly between them.
+// Point and spot lights are attenuated by
+// 1 / (Constant + Linear*d + Quadratic*d*d). NewPointLight and NewSpotLight
+// set Constant to 1 with no falloff, so that the light is as bright as a
+// directional light at any distance; set Quadratic to 1 for the physical
+// inverse square law, in scene units. If ShadowMap is set, the light is
+// occluded by whatever was drawn into it.
+type Light struct {
+	Type       LightType
+	Position   Vector
+	Direction  Vector
+	Color      Color
+	Intensity  float64
+	Constant   float64
+	Linear     float64
+	Quadratic  float64
+	InnerAngle float64
+	OuterAngle float64
+	ShadowMap  *ShadowMap
+}
+
+// NewDirectionalLight :
+func NewDirectionalLight(direction Vector, color Color) *Light {
+	return &Light{
+		Type:      LightDirectional,
+		Direction: direction.Normalize(),
+		Color:     color,
+		Intensity: 1,
+	}
+}
+
+// NewPointLight :
+func NewPointLight(position Vector, color Color) *Light {
+	return &Light{
+		Type:      LightPoint,
+		Position:  position,
+		Color:     color,
+		Intensity: 1,
+		Constant:  1,
+	}
+}
+
+// NewSpotLight :
+func NewSpotLight(position, direction Vector, color Color, inner, outer float64) *Light {
+	return &Light{
+		Type:       LightSpot,
+		Position:   position,
+		Direction:  direction.Normalize(),
+		Color:      color,
+		Intensity:  1,
+		Constant:   1,
+		InnerAngle: inner,
+		OuterAngle: outer,
+	}
+}
+
+// Illuminate returns the unit direction from position towards the light and
+// the light's color at that position after attenuation, spot falloff and
+// shadowing.
+func (l *Light) Illuminate(position Vector) (Vector, Color) {
+	k := l.Intensity
+	if l.ShadowMap != nil {
+		k *= l.ShadowMap.Visibility(position)
+	}
+	if l.Type == LightDirectional {
+		return l.Direction, l.Color.MulScalar(k)
+	}
+	d := l.Position.Sub(position)
+	distance := d.Length()
+	direction := d.DivScalar(distance)
+	if a := l.Constant + l.Linear*distance + l.Quadratic*distance*distance; a > 0 {
+		k /= a
+	}
+	if l.Type == LightSpot {
+		inner := math.Cos(Radians(l.InnerAngle))
+		outer := math.Cos(Radians(l.OuterAngle))
+		k *= smoothstep(outer, inner, direction.Negate().Dot(l.Direction))
+	}
+	return direction, l.Color.MulScalar(k)
+}
+
+func smoothstep(e0, e1, x float64) float64 {
+	if e0 == e1 {
+		if x < e0 {
+			return 0
+		}
+		return 1
+	}
+	t := Clamp((x-e0)/(e1-e0), 0, 1)
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,61 @@
+package fauxgl
+
+// Shading selects the shader that a Scene uses for a material.
+type Shading int
+
+// Shadings :
+const (
+	_ Shading = iota
+	ShadingPhong
+	ShadingPBR
+	ShadingToon
+	ShadingGooch
+	ShadingMatcap
+)
+
+// Material describes the surface appearance of a mesh. Color is the diffuse
+// color; a non-nil Texture replaces it when shading. Ambient is the ambient
+// light color, used as PhongShader's AmbientColor. NormalTexture holds an
+// optional tangent-space normal map.
+//
+// Materials are drawn with PhongShader unless Shading selects another
+// shader. ShadingPBR uses PBRShader with Color and Texture as the base color
+// and the metallic, roughness, occlusion and emissive inputs below.
+// ShadingToon uses ToonShader with Ramp, if set, ShadingGooch uses
+// GoochShader with Color, and ShadingMatcap uses MatcapShader with Matcap
+// tinted by Color.
+//
+// Errors holds any errors from loading the material's textures, which leave
+// those textures unset rather than failing the whole load.
+type Material struct {
+	Name                     string
+	Shading                  Shading
+	Color                    Color
+	Ambient                  Color
+	Specular                 Color
+	SpecularPower            float64
+	Texture                  Texture
+	NormalTexture            Texture
+	Metallic                 float64
+	Roughness                float64
+	MetallicRoughnessTexture Texture
+	OcclusionTexture         Texture
+	Emissive                 Color
+	EmissiveTexture          Texture
+	Ramp                     Texture
+	Matcap                   Texture
+	Errors                   []error
+}
+
+// NewMaterial :
+func NewMaterial(name string, color Color) *Material {
+	return &Material{
+		Name:          name,
+		Color:         color,
+		Ambient:       Color{0.2, 0.2, 0.2, 1},
+		Specular:      White,
+		SpecularPower: 32,
+		Roughness:     0.5,
+		Emissive:      Black,
+	}
+}
@@ -10,6 +10,8 @@ type Matrix struct {
 	X30, X31, X32, X33 float64
 }
 
+var identity = Identity()
+
 // Identity :
 func Identity() Matrix {
 	return Matrix{
@@ -226,6 +226,16 @@ func (m *Mesh) SaveSTL(path string) error {
 	return SaveSTL(path, m)
 }
 
+// SaveOBJ :
+func (m *Mesh) SaveOBJ(path string) error {
+	return SaveOBJ(path, m)
+}
+
+// SavePLY :
+func (m *Mesh) SavePLY(path string) error {
+	return SavePLY(path, m)
+}
+
 // Silhouette :
 func (m *Mesh) Silhouette(eye Vector, offset float64) *Mesh {
 	return silhouette(m, eye, offset)
@@ -0,0 +1,96 @@
+package fauxgl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadMTL reads a Wavefront material library, returning its materials by
+// name. Texture maps are loaded relative to the library's directory. A map
+// that cannot be loaded, such as a missing file or an unsupported image
+// format, does not fail the library: it is left unset and its error is
+// recorded in the material's Errors.
+func LoadMTL(path string) (map[string]*Material, error) {
+	return loadMTL(path, make(map[string]Texture))
+}
+
+// loadMTL caches texture maps by path in textures; if textures is nil, maps
+// are not loaded at all.
+func loadMTL(path string, textures map[string]Texture) (map[string]*Material, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	dir := filepath.Dir(path)
+	materials := make(map[string]*Material)
+	var material *Material
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		keyword := fields[0]
+		args := fields[1:]
+		if keyword == "newmtl" {
+			name := strings.Join(args, " ")
+			material = NewMaterial(name, White)
+			materials[name] = material
+			continue
+		}
+		if material == nil || len(args) == 0 {
+			continue
+		}
+		switch keyword {
+		case "Kd":
+			material.Color = mtlColor(args).Alpha(material.Color.A)
+		case "Ka":
+			material.Ambient = mtlColor(args)
+		case "Ks":
+			material.Specular = mtlColor(args)
+		case "Ns":
+			material.SpecularPower = ParseFloats(args[:1])[0]
+		case "d":
+			material.Color.A = ParseFloats(args[:1])[0]
+		case "Tr":
+			material.Color.A = 1 - ParseFloats(args[:1])[0]
+		case "map_Kd":
+			material.Texture = mtlTexture(material, dir, args, textures, LoadTexture)
+		case "map_Bump", "map_bump", "bump", "norm":
+			material.NormalTexture = mtlTexture(material, dir, args, textures, LoadLinearTexture)
+		}
+	}
+	return materials, scanner.Err()
+}
+
+func mtlColor(args []string) Color {
+	f := ParseFloats(args)
+	for len(f) < 3 {
+		// a single value applies to all channels
+		f = append(f, f[0])
+	}
+	return Color{f[0], f[1], f[2], 1}
+}
+
+func mtlTexture(material *Material, dir string, args []string, textures map[string]Texture, load func(string) (Texture, error)) Texture {
+	if textures == nil {
+		return nil
+	}
+	// options such as -bm 1.0 precede the file name, which may not
+	// contain spaces
+	path := filepath.Join(dir, filepath.FromSlash(args[len(args)-1]))
+	if texture, ok := textures[path]; ok {
+		return texture
+	}
+	texture, err := load(path)
+	if err != nil {
+		material.Errors = append(material.Errors, err)
+		return nil
+	}
+	textures[path] = texture
+	return texture
+}
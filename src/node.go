@@ -0,0 +1,124 @@
+package fauxgl
+
+import "strings"
+
+// Node is an element of a scene graph. Its Matrix is relative to its parent,
+// and a node that is not Visible hides its entire subtree. If Material is
+// set, it takes precedence over Color.
+type Node struct {
+	Name     string
+	Mesh     *Mesh
+	Color    Color
+	Material *Material
+	Matrix   Matrix
+	Visible  bool
+	Parent   *Node
+	Children []*Node
+}
+
+// CreateNode :
+func CreateNode(name string, mesh *Mesh, color Color) *Node {
+	n := &Node{}
+	n.Name = name
+	n.Mesh = mesh
+	n.Color = color
+	n.Matrix = Identity()
+	n.Visible = true
+	return n
+}
+
+// AddChild attaches child to n, detaching it from its previous parent.
+func (n *Node) AddChild(child *Node) {
+	if child.Parent != nil {
+		child.Parent.RemoveChild(child)
+	}
+	child.Parent = n
+	n.Children = append(n.Children, child)
+}
+
+// RemoveChild :
+func (n *Node) RemoveChild(child *Node) {
+	for i, c := range n.Children {
+		if c == child {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			child.Parent = nil
+			return
+		}
+	}
+}
+
+// WorldMatrix returns the node's matrix composed with those of its ancestors.
+func (n *Node) WorldMatrix() Matrix {
+	m := n.Matrix
+	for p := n.Parent; p != nil; p = p.Parent {
+		m = p.Matrix.Mul(m)
+	}
+	return m
+}
+
+// Path returns the slash-separated names from the root down to n.
+func (n *Node) Path() string {
+	var names []string
+	for p := n; p.Parent != nil; p = p.Parent {
+		names = append([]string{p.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// Find returns the first descendant named name, searching depth first.
+func (n *Node) Find(name string) *Node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+		if result := c.Find(name); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// FindPath resolves a slash-separated path of child names relative to n.
+func (n *Node) FindPath(path string) *Node {
+	node := n
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		var next *Node
+		for _, c := range node.Children {
+			if c.Name == name {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// Walk calls fn for n and every visible descendant with its world matrix.
+// Hidden nodes and their subtrees are skipped.
+func (n *Node) Walk(fn func(node *Node, matrix Matrix)) {
+	var parent Matrix
+	if n.Parent != nil {
+		parent = n.Parent.WorldMatrix()
+	} else {
+		parent = Identity()
+	}
+	n.walk(parent, fn)
+}
+
+func (n *Node) walk(parent Matrix, fn func(*Node, Matrix)) {
+	if !n.Visible {
+		return
+	}
+	matrix := parent.Mul(n.Matrix)
+	fn(n, matrix)
+	for _, c := range n.Children {
+		c.walk(matrix, fn)
+	}
+}
@@ -3,6 +3,7 @@ package fauxgl
 import (
 	"bufio"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -16,17 +17,80 @@ func parseIndex(value string, length int) int {
 	return n
 }
 
-// LoadOBJ :
+type objGroup struct {
+	name      string
+	material  string
+	triangles []*Triangle
+}
+
+// LoadOBJ loads an OBJ file as a single mesh. If the file references a
+// material library, the diffuse color of each face's material is stored in
+// its vertex colors; its texture maps are not loaded.
 func LoadOBJ(path string) (*Mesh, error) {
-	file, err := os.Open(path)
+	groups, materials, err := loadOBJ(path, false)
 	if err != nil {
 		return nil, err
 	}
+	var triangles []*Triangle
+	for _, g := range groups {
+		if m, ok := materials[g.material]; ok {
+			for _, t := range g.triangles {
+				t.SetColor(m.Color)
+			}
+		}
+		triangles = append(triangles, g.triangles...)
+	}
+	return NewTriangleMesh(triangles), nil
+}
+
+// LoadOBJScene loads an OBJ file and its material library as a node tree.
+// The root has a child for each object or group (named by its "g" or "o"
+// line), which in turn has a child per material holding the faces that use
+// it, with that Material attached. Tangents are generated for meshes whose
+// material has a normal map. Texture maps are loaded as by LoadMTL, and a
+// missing material library leaves its materials undefined.
+func LoadOBJScene(path string) (*Node, error) {
+	groups, materials, err := loadOBJ(path, true)
+	if err != nil {
+		return nil, err
+	}
+	root := CreateNode("", nil, Discard)
+	parents := make(map[string]*Node)
+	for _, g := range groups {
+		parent, ok := parents[g.name]
+		if !ok {
+			parent = CreateNode(g.name, nil, Discard)
+			parents[g.name] = parent
+			root.AddChild(parent)
+		}
+		node := CreateNode(g.material, NewTriangleMesh(g.triangles), Discard)
+		node.Material = materials[g.material]
+		if node.Material != nil && node.Material.NormalTexture != nil {
+			node.Mesh.ComputeTangents()
+		}
+		parent.AddChild(node)
+	}
+	return root, nil
+}
+
+func loadOBJ(path string, loadTextures bool) ([]*objGroup, map[string]*Material, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer file.Close()
+	dir := filepath.Dir(path)
 	vs := make([]Vector, 1, 1024)  // 1-based indexing
 	vts := make([]Vector, 1, 1024) // 1-based indexing
 	vns := make([]Vector, 1, 1024) // 1-based indexing
-	var triangles []*Triangle
+	materials := make(map[string]*Material)
+	var textures map[string]Texture
+	if loadTextures {
+		textures = make(map[string]Texture)
+	}
+	var groups []*objGroup
+	lookup := make(map[[2]string]*objGroup)
+	var object, group, material string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -49,7 +113,39 @@ func LoadOBJ(path string) (*Mesh, error) {
 			f := ParseFloats(args)
 			v := Vector{f[0], f[1], f[2]}
 			vns = append(vns, v)
+		case "mtllib":
+			for _, name := range args {
+				m, err := loadMTL(filepath.Join(dir, name), textures)
+				if os.IsNotExist(err) {
+					// a missing library leaves its materials undefined
+					continue
+				}
+				if err != nil {
+					return nil, nil, err
+				}
+				for k, v := range m {
+					materials[k] = v
+				}
+			}
+		case "usemtl":
+			material = strings.Join(args, " ")
+		case "o":
+			object = strings.Join(args, " ")
+			group = ""
+		case "g":
+			group = strings.Join(args, " ")
 		case "f":
+			name := group
+			if name == "" {
+				name = object
+			}
+			key := [2]string{name, material}
+			g, ok := lookup[key]
+			if !ok {
+				g = &objGroup{name: name, material: material}
+				lookup[key] = g
+				groups = append(groups, g)
+			}
 			fvs := make([]int, len(args))
 			fvts := make([]int, len(args))
 			fvns := make([]int, len(args))
@@ -72,9 +168,9 @@ func LoadOBJ(path string) (*Mesh, error) {
 				t.V2.Texture = vts[fvts[i2]]
 				t.V3.Texture = vts[fvts[i3]]
 				t.FixNormals()
-				triangles = append(triangles, &t)
+				g.triangles = append(g.triangles, &t)
 			}
 		}
 	}
-	return NewTriangleMesh(triangles), scanner.Err()
+	return groups, materials, scanner.Err()
 }
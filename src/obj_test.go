@@ -0,0 +1,105 @@
+package fauxgl
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+const testOBJ = `mtllib materials.mtl missing.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+o box
+usemtl red
+f 1/1 2/2 3/3 4/4
+g lid
+usemtl textured
+f 1/1 3/3 4/4
+`
+
+const testMTL = `newmtl red
+Ka 0.1 0.2 0.3
+Kd 1 0 0
+Ks 0.5 0.5 0.5
+Ns 10
+d 0.5
+
+newmtl textured
+Kd 0.5
+map_Kd -bm 1 texture.png
+map_Bump missing.png
+`
+
+func writeOBJScene(t *testing.T) string {
+	dir := tempDir(t)
+	writeFile(t, filepath.Join(dir, "scene.obj"), testOBJ)
+	writeFile(t, filepath.Join(dir, "materials.mtl"), testMTL)
+	texture := uniformImage(2, 2, color.NRGBA{255, 255, 255, 255})
+	if err := SavePNG(filepath.Join(dir, "texture.png"), texture); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "scene.obj")
+}
+
+func TestLoadOBJ(t *testing.T) {
+	mesh, err := LoadOBJ(writeOBJScene(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the quad is split into two triangles
+	if len(mesh.Triangles) != 3 {
+		t.Fatalf("got %d triangles, want 3", len(mesh.Triangles))
+	}
+	if got, want := mesh.Triangles[0].V1.Color, (Color{1, 0, 0, 0.5}); got != want {
+		t.Errorf("vertex color = %v, want %v", got, want)
+	}
+	if got, want := mesh.Triangles[0].V2.Texture, (Vector{1, 0, 0}); got != want {
+		t.Errorf("texture coordinate = %v, want %v", got, want)
+	}
+}
+
+func TestLoadOBJScene(t *testing.T) {
+	root, err := LoadOBJScene(writeOBJScene(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	red := root.FindPath("box/red")
+	textured := root.FindPath("lid/textured")
+	if red == nil || textured == nil {
+		t.Fatalf("missing nodes in %v", root.Children)
+	}
+	m := red.Material
+	if m.Color != (Color{1, 0, 0, 0.5}) || m.Specular != (Color{0.5, 0.5, 0.5, 1}) || m.SpecularPower != 10 {
+		t.Errorf("red material = %+v", m)
+	}
+	if m.Ambient != (Color{0.1, 0.2, 0.3, 1}) {
+		t.Errorf("red ambient = %v", m.Ambient)
+	}
+	scene := CreateScene(1, 1)
+	if shader := scene.shader(Identity(), Identity(), White, m).(*PhongShader); shader.AmbientColor != m.Ambient {
+		t.Errorf("shader ambient = %v, want %v", shader.AmbientColor, m.Ambient)
+	}
+	m = textured.Material
+	if m.Color != (Color{0.5, 0.5, 0.5, 1}) {
+		t.Errorf("textured color = %v", m.Color)
+	}
+	if m.Texture == nil {
+		t.Error("map_Kd was not loaded")
+	}
+	// the missing bump map is reported without failing the load
+	if m.NormalTexture != nil || len(m.Errors) != 1 {
+		t.Errorf("missing map_Bump: texture %v, errors %v", m.NormalTexture, m.Errors)
+	}
+}
+
+func TestLoadMTLMissing(t *testing.T) {
+	if _, err := LoadMTL(filepath.Join(tempDir(t), "missing.mtl")); err == nil {
+		t.Error("LoadMTL succeeded for a missing file")
+	}
+}
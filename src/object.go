@@ -1,10 +1,12 @@
 package fauxgl
 
-// Object :
+// Object is a mesh placed in a Scene by Matrix. If Material is set, it
+// takes precedence over Color.
 type Object struct {
-	Mesh   *Mesh
-	Color  Color
-	Matrix Matrix
+	Mesh     *Mesh
+	Color    Color
+	Material *Material
+	Matrix   Matrix
 }
 
 // CreateObject :
@@ -0,0 +1,172 @@
+package fauxgl
+
+import "math"
+
+// Outline configures the image-space outline post-process, which finds
+// edges where the depth, the normal or the object id changes abruptly and
+// draws them in Color. Matrix must be the matrix that the scene was drawn
+// with, as for SSAO. Width is in output pixels.
+//
+// DepthThreshold is the relative change in the slope of the depth that
+// counts as an edge, so that planes seen at grazing angles are not
+// outlined. NormalThreshold is the angle in radians between neighboring
+// normals that counts as a crease; zero disables creases. Normals, if set,
+// should hold world space normals such as those written by GBufferShader;
+// otherwise they are estimated from the depth buffer. Object ids are used
+// when the context has an id buffer, such as after Scene.Render.
+type Outline struct {
+	Matrix          Matrix
+	Color           Color
+	Width           float64
+	DepthThreshold  float64
+	NormalThreshold float64
+	Normals         *Attachment
+}
+
+// NewOutline :
+func NewOutline(matrix Matrix) *Outline {
+	return &Outline{
+		Matrix:          matrix,
+		Color:           Black,
+		Width:           1,
+		DepthThreshold:  0.05,
+		NormalThreshold: Radians(30),
+	}
+}
+
+// Edges reports, for every sample of the depth buffer, whether it lies on
+// an edge. Each edge is marked on one side only: on the nearer surface at
+// depth and id changes, where the outline belongs.
+func (dc *Context) Edges(outline *Outline) []bool {
+	w, h := dc.Width, dc.Height
+	unproject := outline.Matrix.Inverse().Mul(dc.screenMatrix.Inverse())
+	position := func(x, y int) (Vector, bool) {
+		z := dc.DepthBuffer[y*w+x]
+		if z == math.MaxFloat64 {
+			return Vector{}, false
+		}
+		p := unproject.MulPositionW(Vector{float64(x) + 0.5, float64(y) + 0.5, z})
+		return p.DivScalar(p.W).Vector(), true
+	}
+
+	// reciprocal view depth, which is linear in screen space across planes,
+	// and normals
+	inverse := make([]float64, w*h)
+	normals := make([]Vector, w*h)
+	parallelRows(h, func(y int) {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			p, ok := position(x, y)
+			if !ok {
+				continue
+			}
+			inverse[i] = 1 / outline.Matrix.MulPositionW(p).W
+			if outline.NormalThreshold <= 0 {
+				continue
+			}
+			if outline.Normals != nil {
+				c := outline.Normals.Buffer[i]
+				normals[i] = Vector{c.R, c.G, c.B}.Normalize()
+			} else {
+				normals[i] = dc.estimateNormal(x, y, p, unproject, position)
+			}
+		}
+	})
+
+	crease := math.Cos(outline.NormalThreshold)
+	edges := make([]bool, w*h)
+	parallelRows(h, func(y int) {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if inverse[i] == 0 {
+				continue
+			}
+			edge := false
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+				nx, ny := x+d[0], y+d[1]
+				if nx < 0 || ny < 0 || nx >= w || ny >= h {
+					continue
+				}
+				j := ny*w + nx
+				if inverse[j] == 0 {
+					// silhouette against the background
+					edge = true
+					break
+				}
+				if dc.IDBuffer != nil && dc.IDBuffer[i] != dc.IDBuffer[j] && inverse[i] >= inverse[j] {
+					edge = true
+					break
+				}
+				// creases are marked on the right and lower side only
+				if outline.NormalThreshold > 0 && (d[0] > 0 || d[1] > 0) {
+					a, b := normals[i], normals[j]
+					if a != (Vector{}) && b != (Vector{}) && a.Dot(b) < crease {
+						edge = true
+						break
+					}
+				}
+				// depth discontinuity: the nearer side bulges out of the
+				// plane through its neighbors
+				px, py := x-d[0], y-d[1]
+				if px < 0 || py < 0 || px >= w || py >= h {
+					continue
+				}
+				k := py*w + px
+				if inverse[k] == 0 {
+					continue
+				}
+				l := inverse[j] + inverse[k] - 2*inverse[i]
+				if -l/inverse[i] > outline.DepthThreshold {
+					edge = true
+					break
+				}
+			}
+			edges[i] = edge
+		}
+	})
+	return edges
+}
+
+// DrawOutline finds edges and draws them into the color buffer, or into
+// the HDR buffer for HDR contexts, blending Color by its alpha.
+func (dc *Context) DrawOutline(outline *Outline) {
+	dc.CompositeFragments()
+	edges := dc.Edges(outline)
+	w, h := dc.Width, dc.Height
+	r := outline.Width * float64(dc.Scale) / 2
+	ri := int(math.Ceil(r))
+	parallelRows(h, func(y int) {
+		for x := 0; x < w; x++ {
+			// is there an edge within the line's radius?
+			found := edges[y*w+x]
+			for dy := -ri; dy <= ri && !found; dy++ {
+				for dx := -ri; dx <= ri && !found; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					if float64(dx*dx+dy*dy) > r*r {
+						continue
+					}
+					found = edges[ny*w+nx]
+				}
+			}
+			if !found {
+				continue
+			}
+			i := y*w + x
+			a := Clamp(outline.Color.A, 0, 1)
+			src := outline.Color.MulScalar(a).Alpha(a)
+			if dc.HDR && dc.HDRBuffer != nil {
+				dc.HDRBuffer[i] = BlendSourceOver.Apply(src, dc.HDRBuffer[i])
+				continue
+			}
+			c := dc.decode(x, y)
+			c = BlendSourceOver.Apply(src, c.MulScalar(c.A).Alpha(c.A))
+			if c.A > 0 {
+				c = c.DivScalar(c.A).Alpha(c.A)
+			}
+			dc.ColorBuffer.SetNRGBA(x, y, dc.encode(c))
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package fauxgl
+
+import "math"
+
+// PBRShader implements physically based shading with the metallic-roughness
+// model used by glTF: a Lambertian diffuse term and a Cook-Torrance specular
+// term with the GGX distribution, Smith geometry and Schlick Fresnel.
+//
+// Each input is a constant factor that is multiplied by its texture, when
+// one is set. MetallicRoughnessTexture holds roughness in its green channel
+// and metallic in its blue channel, OcclusionTexture holds ambient occlusion
+// in its red channel, and NormalTexture is a tangent space normal map; these
+// should be loaded as linear data. BaseColorTexture and EmissiveTexture are
+// sRGB encoded colors. If BaseColor is Discard, vertex colors are used.
+//
+// Lights are handled like in PhongShader. Light colors are taken as the
+// irradiance on a surface facing the light, so that a white light on a
+// white, rough dielectric facing it gives white. Indirect light comes from
+// Environment when one is set, or else is a constant AmbientColor.
+type PBRShader struct {
+	Matrix                   Matrix
+	Model                    Matrix
+	LightDirection           Vector
+	Lights                   []*Light
+	CameraPosition           Vector
+	BaseColor                Color
+	BaseColorTexture         Texture
+	Metallic                 float64
+	Roughness                float64
+	MetallicRoughnessTexture Texture
+	NormalTexture            Texture
+	OcclusionTexture         Texture
+	OcclusionStrength        float64
+	Emissive                 Color
+	EmissiveTexture          Texture
+	AmbientColor             Color
+	Environment              *Environment
+	ShadowMap                *ShadowMap
+	normals                  normalMatrix
+}
+
+// NewPBRShader :
+func NewPBRShader(matrix Matrix, lightDirection, cameraPosition Vector) *PBRShader {
+	return &PBRShader{
+		Matrix:            matrix,
+		Model:             Identity(),
+		LightDirection:    lightDirection,
+		CameraPosition:    cameraPosition,
+		BaseColor:         White,
+		Roughness:         0.5,
+		OcclusionStrength: 1,
+		Emissive:          Black,
+		AmbientColor:      Color{0.2, 0.2, 0.2, 1},
+	}
+}
+
+// Vertex :
+func (shader *PBRShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
+	v.Output = shader.Matrix.MulPositionW(v.Position)
+	return v
+}
+
+func (shader *PBRShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
+// Fragment :
+func (shader *PBRShader) Fragment(v Vertex) Color {
+	sample := func(t Texture) Color {
+		return sampleTexture(t, v)
+	}
+	base := shader.BaseColor
+	if base == Discard {
+		base = v.Color
+	}
+	if shader.BaseColorTexture != nil {
+		base = base.Mul(sample(shader.BaseColorTexture))
+	}
+	metallic := shader.Metallic
+	roughness := shader.Roughness
+	if shader.MetallicRoughnessTexture != nil {
+		c := sample(shader.MetallicRoughnessTexture)
+		roughness *= c.G
+		metallic *= c.B
+	}
+	metallic = Clamp(metallic, 0, 1)
+	// very low roughness makes point highlights vanish
+	roughness = Clamp(roughness, 0.03, 1)
+	occlusion := 1.0
+	if shader.OcclusionTexture != nil {
+		occlusion = 1 + shader.OcclusionStrength*(sample(shader.OcclusionTexture).R-1)
+	}
+	if shader.NormalTexture != nil {
+		v.Normal = PerturbNormal(v, shader.NormalTexture)
+	}
+
+	n := v.Normal
+	view := shader.CameraPosition.Sub(v.Position).Normalize()
+	if n.Dot(view) < 0 {
+		// seen from behind, as with double sided materials
+		n = n.Negate()
+	}
+	nv := math.Max(n.Dot(view), 1e-4)
+	albedo := base.Alpha(1)
+	f0 := Color{0.04, 0.04, 0.04, 1}.Lerp(albedo, metallic)
+	diffuse := albedo.MulScalar(1 - metallic)
+
+	// direct lighting
+	var light Color
+	direct := func(l Vector, radiance Color) {
+		nl := n.Dot(l)
+		if nl <= 0 {
+			return
+		}
+		h := l.Add(view).Normalize()
+		f := fresnelSchlick(f0, math.Max(h.Dot(view), 0))
+		d := ggxDistribution(n.Dot(h), roughness)
+		g := smithGeometry(nv, nl, roughness)
+		// light colors are irradiance, so the specular term is scaled by
+		// pi and the diffuse term drops its 1 / pi
+		specular := f.MulScalar(d * g / (4 * nv * nl) * math.Pi)
+		kd := White.Sub(f)
+		c := kd.Mul(diffuse).Add(specular)
+		light = light.Add(c.Mul(radiance).MulScalar(nl))
+	}
+	if len(shader.Lights) == 0 {
+		radiance := White
+		if shader.ShadowMap != nil {
+			radiance = radiance.MulScalar(shader.ShadowMap.Visibility(v.Position))
+		}
+		direct(shader.LightDirection, radiance)
+	} else {
+		for _, l := range shader.Lights {
+			direct(l.Illuminate(v.Position))
+		}
+	}
+
+	// indirect lighting
+	var ambient Color
+	if e := shader.Environment; e != nil {
+		f := fresnelSchlickRoughness(f0, nv, roughness)
+		kd := White.Sub(f)
+		irradiance := e.Irradiance(n)
+		ambient = kd.Mul(diffuse).Mul(irradiance)
+		r := view.Negate().Reflect(n)
+		ambient = ambient.Add(e.Radiance(r, roughness).Mul(envBRDF(f0, nv, roughness)))
+	} else {
+		ambient = shader.AmbientColor.Mul(albedo)
+	}
+	light = light.Add(ambient.MulScalar(occlusion))
+
+	emissive := shader.Emissive
+	if shader.EmissiveTexture != nil {
+		emissive = emissive.Mul(sample(shader.EmissiveTexture))
+	}
+	light = light.Add(emissive)
+	return light.Alpha(base.A)
+}
+
+// ggxDistribution is the Trowbridge-Reitz (GGX) normal distribution.
+func ggxDistribution(nh, roughness float64) float64 {
+	a := roughness * roughness
+	a2 := a * a
+	d := nh*nh*(a2-1) + 1
+	return a2 / (math.Pi * d * d)
+}
+
+// smithGeometry is the Smith geometry term with Schlick's approximation,
+// remapped for direct lighting.
+func smithGeometry(nv, nl, roughness float64) float64 {
+	k := (roughness + 1) * (roughness + 1) / 8
+	return nv / (nv*(1-k) + k) * nl / (nl*(1-k) + k)
+}
+
+func fresnelSchlick(f0 Color, c float64) Color {
+	return f0.Add(White.Sub(f0).MulScalar(math.Pow(1-c, 5))).Alpha(1)
+}
+
+// fresnelSchlickRoughness damps the Fresnel term of rough surfaces for
+// ambient light, which arrives from every direction.
+func fresnelSchlickRoughness(f0 Color, c, roughness float64) Color {
+	g := 1 - roughness
+	max := Color{math.Max(g, f0.R), math.Max(g, f0.G), math.Max(g, f0.B), 1}
+	return f0.Add(max.Sub(f0).MulScalar(math.Pow(1-c, 5))).Alpha(1)
+}
+
+// envBRDF is Karis' analytic fit of the split sum environment BRDF.
+func envBRDF(f0 Color, nv, roughness float64) Color {
+	c0 := [4]float64{-1, -0.0275, -0.572, 0.022}
+	c1 := [4]float64{1, 0.0425, 1.04, -0.04}
+	r := [4]float64{}
+	for i := range r {
+		r[i] = roughness*c0[i] + c1[i]
+	}
+	a004 := math.Min(r[0]*r[0], math.Exp2(-9.28*nv))*r[0] + r[1]
+	a := a004*-1.04 + r[2]
+	b := a004*1.04 + r[3]
+	return f0.MulScalar(a).AddScalar(b).Alpha(1)
+}
@@ -3,6 +3,7 @@ package fauxgl
 import (
 	"bufio"
 	"encoding/binary"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -68,7 +69,7 @@ type plyElement struct {
 	properties []plyProperty
 }
 
-// LoadPLY :
+// LoadPLY loads the faces of a PLY file, and its edges as lines.
 func LoadPLY(path string) (*Mesh, error) {
 	// open file
 	file, err := os.Open(path)
@@ -143,6 +144,7 @@ func loadPlyASCII(file *os.File, elements []plyElement) (*Mesh, error) {
 	scanner := bufio.NewScanner(file)
 	var vertexes []Vector
 	var triangles []*Triangle
+	var lines []*Line
 	for _, element := range elements {
 		for i := 0; i < element.count; i++ {
 			scanner.Scan()
@@ -150,7 +152,19 @@ func loadPlyASCII(file *os.File, elements []plyElement) (*Mesh, error) {
 			f := strings.Fields(line)
 			fi := 0
 			vertex := Vector{}
+			var edge [2]int
 			for _, property := range element.properties {
+				if property.name == "vertex1" || property.name == "vertex2" {
+					index, err := strconv.ParseInt(f[fi], 0, 0)
+					if err != nil {
+						return nil, err
+					}
+					if property.name == "vertex1" {
+						edge[0] = int(index)
+					} else {
+						edge[1] = int(index)
+					}
+				}
 				if property.name == "x" {
 					vertex.X, _ = strconv.ParseFloat(f[fi], 64)
 				}
@@ -177,18 +191,27 @@ func loadPlyASCII(file *os.File, elements []plyElement) (*Mesh, error) {
 			if element.name == "vertex" {
 				vertexes = append(vertexes, vertex)
 			}
+			if element.name == "edge" {
+				line, err := plyLine(vertexes, edge)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, line)
+			}
 		}
 	}
-	return NewTriangleMesh(triangles), nil
+	return NewMesh(triangles, lines), nil
 }
 
 func loadPlyBinary(file *os.File, elements []plyElement, order binary.ByteOrder) (*Mesh, error) {
 	var vertexes []Vector
 	var triangles []*Triangle
+	var lines []*Line
 	for _, element := range elements {
 		for i := 0; i < element.count; i++ {
 			var vertex Vector
 			var points []Vector
+			var edge [2]int
 			for _, property := range element.properties {
 				if property.countType == plyNone {
 					value, err := readPlyFloat(file, order, property.dataType)
@@ -204,6 +227,12 @@ func loadPlyBinary(file *os.File, elements []plyElement, order binary.ByteOrder)
 					if property.name == "z" {
 						vertex.Z = value
 					}
+					if property.name == "vertex1" {
+						edge[0] = int(value)
+					}
+					if property.name == "vertex2" {
+						edge[1] = int(value)
+					}
 				} else {
 					count, err := readPlyInt(file, order, property.countType)
 					if err != nil {
@@ -231,9 +260,25 @@ func loadPlyBinary(file *os.File, elements []plyElement, order binary.ByteOrder)
 				t.FixNormals()
 				triangles = append(triangles, &t)
 			}
+			if element.name == "edge" {
+				line, err := plyLine(vertexes, edge)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return NewMesh(triangles, lines), nil
+}
+
+func plyLine(vertexes []Vector, edge [2]int) (*Line, error) {
+	for _, i := range edge {
+		if i < 0 || i >= len(vertexes) {
+			return nil, fmt.Errorf("invalid ply edge vertex: %d", i)
 		}
 	}
-	return NewTriangleMesh(triangles), nil
+	return NewLineForPoints(vertexes[edge[0]], vertexes[edge[1]]), nil
 }
 
 func readPlyInt(file *os.File, order binary.ByteOrder, dataType plyDataType) (int, error) {
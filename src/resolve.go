@@ -0,0 +1,152 @@
+package fauxgl
+
+import (
+	"image"
+	"math"
+)
+
+// Resolve selects the filter used to downsample a supersampled context.
+type Resolve int
+
+// Resolves :
+const (
+	_ Resolve = iota
+	ResolveBox
+	ResolveLanczos
+)
+
+func resolve(src *image.NRGBA, scale int, filter Resolve, encoding Encoding) *image.NRGBA {
+	// filter in premultiplied linear space so that transparent samples do
+	// not bleed their color into the result
+	w := src.Rect.Dx()
+	h := src.Rect.Dy()
+	buf := make([]float64, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := src.PixOffset(x, y)
+			j := (y*w + x) * 4
+			a := float64(src.Pix[i+3]) / 0xff
+			for k := 0; k < 3; k++ {
+				v := src.Pix[i+k]
+				if encoding == EncodingSRGB {
+					buf[j+k] = srgbTable[v] * a
+				} else {
+					buf[j+k] = float64(v) / 0xff * a
+				}
+			}
+			buf[j+3] = a
+		}
+	}
+	var kernel func(float64) float64
+	var support float64
+	if filter == ResolveLanczos {
+		kernel = lanczos3
+		support = 3
+	} else {
+		kernel = box
+		support = 0.5
+	}
+	dw := w / scale
+	dh := h / scale
+	buf = resample(buf, w, h, dw, scale, true, kernel, support)
+	buf = resample(buf, dw, h, dh, scale, false, kernel, support)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for i := 0; i < dw*dh; i++ {
+		a := Clamp(buf[i*4+3], 0, 1)
+		c := Color{}
+		if a > 0 {
+			c = Color{buf[i*4] / a, buf[i*4+1] / a, buf[i*4+2] / a, a}
+		}
+		nrgba := encodeNRGBA(c, encoding)
+		dst.Pix[i*4+0] = nrgba.R
+		dst.Pix[i*4+1] = nrgba.G
+		dst.Pix[i*4+2] = nrgba.B
+		dst.Pix[i*4+3] = nrgba.A
+	}
+	return dst
+}
+
+// resample downsamples the w*h image in buf by scale along one axis, to n
+// columns if horizontal is set or to n rows otherwise.
+func resample(buf []float64, w, h, n, scale int, horizontal bool, kernel func(float64) float64, support float64) []float64 {
+	var length, lines int
+	if horizontal {
+		length, lines = w, h
+	} else {
+		length, lines = h, w
+	}
+	// precompute weights for each output position
+	s := float64(scale)
+	r := int(math.Ceil(support * s))
+	weights := make([][]float64, n)
+	starts := make([]int, n)
+	for i := 0; i < n; i++ {
+		center := (float64(i)+0.5)*s - 0.5
+		start := int(math.Floor(center)) - r + 1
+		var ws []float64
+		var total float64
+		for j := start; j < start+2*r; j++ {
+			wt := kernel((float64(j) - center) / s)
+			ws = append(ws, wt)
+			total += wt
+		}
+		for k := range ws {
+			ws[k] /= total
+		}
+		weights[i] = ws
+		starts[i] = start
+	}
+	var result []float64
+	if horizontal {
+		result = make([]float64, n*h*4)
+	} else {
+		result = make([]float64, w*n*4)
+	}
+	for line := 0; line < lines; line++ {
+		for i := 0; i < n; i++ {
+			var c [4]float64
+			for k, wt := range weights[i] {
+				if wt == 0 {
+					continue
+				}
+				j := ClampInt(starts[i]+k, 0, length-1)
+				var p int
+				if horizontal {
+					p = (line*w + j) * 4
+				} else {
+					p = (j*w + line) * 4
+				}
+				c[0] += buf[p+0] * wt
+				c[1] += buf[p+1] * wt
+				c[2] += buf[p+2] * wt
+				c[3] += buf[p+3] * wt
+			}
+			var q int
+			if horizontal {
+				q = (line*n + i) * 4
+			} else {
+				q = (i*w + line) * 4
+			}
+			copy(result[q:q+4], c[:])
+		}
+	}
+	return result
+}
+
+func box(x float64) float64 {
+	if x >= -0.5 && x < 0.5 {
+		return 1
+	}
+	return 0
+}
+
+func lanczos3(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if x <= -3 || x >= 3 {
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
@@ -0,0 +1,97 @@
+package fauxgl
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResolveBox(t *testing.T) {
+	// each 2x2 block of samples becomes one output pixel
+	src := image.NewNRGBA(image.Rect(0, 0, 6, 2))
+	blocks := [3][4]color.NRGBA{
+		{{255, 255, 255, 255}, {255, 255, 255, 255}, {255, 255, 255, 255}, {255, 255, 255, 255}},
+		{{255, 255, 255, 255}, {0, 0, 0, 255}, {255, 255, 255, 255}, {0, 0, 0, 255}},
+		{{255, 0, 0, 255}, {0, 255, 0, 0}, {255, 0, 0, 255}, {0, 255, 0, 0}},
+	}
+	for i, block := range blocks {
+		for j, c := range block {
+			src.SetNRGBA(i*2+j%2, j/2, c)
+		}
+	}
+	dst := resolve(src, 2, ResolveBox, EncodingSRGB)
+	if dst.Rect.Dx() != 3 || dst.Rect.Dy() != 1 {
+		t.Fatalf("resolved to %v, want 3x1", dst.Rect)
+	}
+	want := []color.NRGBA{
+		{255, 255, 255, 255},
+		// half white is averaged in linear space, not in sRGB
+		{188, 188, 188, 255},
+		// transparent samples do not bleed their color
+		{255, 0, 0, 128},
+	}
+	for i, w := range want {
+		if got := dst.NRGBAAt(i, 0); got != w {
+			t.Errorf("pixel %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestResolveLanczos(t *testing.T) {
+	// a uniform image stays uniform
+	c := color.NRGBA{200, 100, 50, 255}
+	dst := resolve(uniformImage(12, 12, c), 3, ResolveLanczos, EncodingSRGB)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if got := dst.NRGBAAt(x, y); got != c {
+				t.Fatalf("uniform pixel %d, %d = %v, want %v", x, y, got, c)
+			}
+		}
+	}
+
+	// a hard edge stays sharp, but its neighbors are filtered across it
+	src := uniformImage(16, 2, color.NRGBA{0, 0, 0, 255})
+	for y := 0; y < 2; y++ {
+		for x := 8; x < 16; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+		}
+	}
+	box := resolve(src, 2, ResolveBox, EncodingLinear)
+	lanczos := resolve(src, 2, ResolveLanczos, EncodingLinear)
+	row := make([]uint8, 8)
+	for x := range row {
+		row[x] = lanczos.NRGBAAt(x, 0).R
+	}
+	if row[0] != 0 || row[7] != 255 {
+		t.Errorf("lanczos ends = %d, %d, want 0, 255", row[0], row[7])
+	}
+	if row[3] == box.NRGBAAt(3, 0).R || row[4] == box.NRGBAAt(4, 0).R {
+		t.Errorf("lanczos row %v does not filter across the edge", row)
+	}
+	if row[3] >= row[4] {
+		t.Errorf("lanczos row %v is not increasing across the edge", row)
+	}
+}
+
+func TestNewContextWithSamples(t *testing.T) {
+	for _, samples := range []int{1, 4, 9, 16} {
+		dc := NewContextWithSamples(10, 5, samples)
+		if dc.Scale*dc.Scale != samples || dc.Width != 10*dc.Scale || dc.Height != 5*dc.Scale {
+			t.Errorf("%d samples: scale %d, size %dx%d", samples, dc.Scale, dc.Width, dc.Height)
+		}
+		dc.ClearColorBufferWith(White)
+		if r := dc.Image().Bounds(); r.Dx() != 10 || r.Dy() != 5 {
+			t.Errorf("%d samples: image is %v, want 10x5", samples, r)
+		}
+	}
+	for _, samples := range []int{-4, 0, 2, 8} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d samples did not panic", samples)
+				}
+			}()
+			NewContextWithSamples(10, 5, samples)
+		}()
+	}
+}
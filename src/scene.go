@@ -1,49 +1,204 @@
 package fauxgl
 
-var (
-	eye    = V(-1, -2, 2)
-	center = V(-0.07, 0, 0)
-	up     = V(0, 0, 1)
-)
-
 // Scene :
 type Scene struct {
 	Objects         []*Object
+	Root            *Node
 	Camera          *Camera
+	LightDirection  Vector
+	Lights          []*Light
 	BackgroundColor Color
+	Environment     *Environment
 	Width           int
 	Height          int
 	Context         *Context
+	rendered        []sceneMesh
+	matrix          Matrix
+}
+
+// sceneMesh is a mesh to be drawn, with the object or node it came from.
+type sceneMesh struct {
+	Object   *Object
+	Node     *Node
+	Mesh     *Mesh
+	Model    Matrix
+	Color    Color
+	Material *Material
+}
+
+// Hit describes the surface under a pixel, as found by Pick. Exactly one
+// of Object and Node is set. Primitive indexes the mesh's triangles, followed
+// by its lines.
+type Hit struct {
+	Object    *Object
+	Node      *Node
+	Primitive int
+	Position  Vector
 }
 
 // CreateScene :
 func CreateScene(width, height int) *Scene {
 	s := &Scene{}
 	s.Objects = make([]*Object, 0)
+	s.Root = CreateNode("", nil, Discard)
 	s.BackgroundColor = White
 	s.Camera = CreateCamera()
+	s.LightDirection = V(-2, 0, 1).Normalize()
 	s.Width = width
 	s.Height = height
 	s.Context = NewContext(s.Width, s.Height)
-	s.Context.ClearColor = s.BackgroundColor
-
-	aspect := float64(width) / float64(height)
-	matrix := LookAt(eye, center, up).Perspective(20, aspect, 1, 50)
-	light := V(-2, 0, 1).Normalize()
-	color := Color{R: 0.5, G: 1, B: 0.65, A: 1}
-	shader := NewPhongShader(matrix, light, eye)
-	shader.ObjectColor = color
-
-	s.Context.Shader = shader
 	return s
 }
 
-// Render :
+// Render draws every object and visible node. Shadow maps attached to the
+// scene's lights are redrawn from the scene's meshes first. If the scene has
+// an Environment, it is drawn as the background and lights PBR materials.
+// The context's id buffers are filled so that Pick can identify what was
+// drawn; the context's WriteID setting is left as it was.
 func (s *Scene) Render() {
-	s.Context.ClearColorBuffer()
+	meshes := s.meshes()
+	for _, l := range s.Lights {
+		if l.ShadowMap == nil {
+			continue
+		}
+		sm := l.ShadowMap
+		sm.Clear()
+		for _, m := range meshes {
+			sm.DrawMesh(m.Mesh, m.Model)
+		}
+	}
+	dc := s.Context
+	dc.ClearColor = s.BackgroundColor
+	dc.ClearColorBuffer()
+	dc.ClearDepthBuffer()
+	writeID := dc.WriteID
+	dc.WriteID = true
+	dc.ClearIDBuffer()
+	aspect := float64(s.Width) / float64(s.Height)
+	matrix := s.Camera.Matrix(aspect)
+	if s.Environment != nil {
+		dc.DrawEnvironment(s.Environment, matrix)
+	}
+	for i, m := range meshes {
+		dc.Shader = s.shader(matrix, m.Model, m.Color, m.Material)
+		dc.ObjectID = int32(i)
+		dc.DrawMesh(m.Mesh)
+	}
+	dc.CompositeFragments()
+	dc.WriteID = writeID
+	s.rendered = meshes
+	s.matrix = matrix
+}
+
+func (s *Scene) meshes() []sceneMesh {
+	var meshes []sceneMesh
 	for _, obj := range s.Objects {
-		s.Context.DrawMesh(obj.Mesh)
+		meshes = append(meshes, sceneMesh{obj, nil, obj.Mesh, obj.Matrix, obj.Color, obj.Material})
 	}
+	s.Root.Walk(func(node *Node, model Matrix) {
+		if node.Mesh != nil {
+			meshes = append(meshes, sceneMesh{nil, node, node.Mesh, model, node.Color, node.Material})
+		}
+	})
+	return meshes
+}
+
+// Pick returns what covered the pixel at x, y in the last call to Render,
+// with the world space position of the visible surface, or nil if only the
+// background was drawn there.
+func (s *Scene) Pick(x, y int) *Hit {
+	dc := s.Context
+	if s.rendered == nil || dc.IDBuffer == nil {
+		return nil
+	}
+	// use the center sample of a supersampled pixel
+	sx := x*dc.Scale + dc.Scale/2
+	sy := y*dc.Scale + dc.Scale/2
+	if sx < 0 || sy < 0 || sx >= dc.Width || sy >= dc.Height {
+		return nil
+	}
+	i := sy*dc.Width + sx
+	id := int(dc.IDBuffer[i])
+	if id < 0 || id >= len(s.rendered) {
+		return nil
+	}
+	m := s.rendered[id]
+	// unproject the depth buffer back to world space
+	screen := Vector{float64(sx) + 0.5, float64(sy) + 0.5, dc.DepthBuffer[i]}
+	ndc := Screen(dc.Width, dc.Height).Inverse().MulPosition(screen)
+	p := s.matrix.Inverse().MulPositionW(ndc)
+	return &Hit{
+		Object:    m.Object,
+		Node:      m.Node,
+		Primitive: int(dc.PrimitiveBuffer[i]),
+		Position:  p.DivScalar(p.W).Vector(),
+	}
+}
+
+func (s *Scene) shader(matrix, model Matrix, color Color, material *Material) Shader {
+	eye := s.Camera.Eye
+	if material == nil {
+		shader := NewPhongShader(matrix, s.LightDirection, eye)
+		shader.Model = model
+		shader.Lights = s.Lights
+		shader.ObjectColor = color
+		return shader
+	}
+	switch material.Shading {
+	case ShadingPBR:
+		shader := NewPBRShader(matrix, s.LightDirection, eye)
+		shader.Model = model
+		shader.Lights = s.Lights
+		shader.Environment = s.Environment
+		shader.BaseColor = material.Color
+		shader.BaseColorTexture = material.Texture
+		shader.Metallic = material.Metallic
+		shader.Roughness = material.Roughness
+		shader.MetallicRoughnessTexture = material.MetallicRoughnessTexture
+		shader.NormalTexture = material.NormalTexture
+		shader.OcclusionTexture = material.OcclusionTexture
+		shader.Emissive = material.Emissive
+		shader.EmissiveTexture = material.EmissiveTexture
+		return shader
+	case ShadingToon:
+		shader := NewToonShader(matrix, s.LightDirection, eye)
+		shader.Model = model
+		shader.Lights = s.Lights
+		shader.ObjectColor = material.Color
+		shader.Texture = material.Texture
+		shader.Ramp = material.Ramp
+		return shader
+	case ShadingGooch:
+		// Gooch shading has a single key light
+		direction := s.LightDirection
+		for _, l := range s.Lights {
+			if l.Type == LightDirectional {
+				direction = l.Direction.Normalize()
+				break
+			}
+		}
+		shader := NewGoochShader(matrix, direction, eye)
+		shader.Model = model
+		shader.ObjectColor = material.Color
+		return shader
+	case ShadingMatcap:
+		if material.Matcap != nil {
+			shader := NewMatcapShader(matrix, eye, s.Camera.Up, material.Matcap)
+			shader.Model = model
+			shader.ObjectColor = material.Color
+			return shader
+		}
+	}
+	shader := NewPhongShader(matrix, s.LightDirection, eye)
+	shader.Model = model
+	shader.Lights = s.Lights
+	shader.ObjectColor = material.Color
+	shader.AmbientColor = material.Ambient
+	shader.Texture = material.Texture
+	shader.NormalTexture = material.NormalTexture
+	shader.SpecularColor = material.Specular
+	shader.SpecularPower = material.SpecularPower
+	return shader
 }
 
 // AddObject :
@@ -54,6 +209,26 @@ func (s *Scene) AddObject(o *Object) {
 	s.Objects = append(s.Objects, o)
 }
 
+// AddLight adds l to the lights used for every object in the scene. Once
+// any lights are added, LightDirection is ignored.
+func (s *Scene) AddLight(l *Light) {
+	s.Lights = append(s.Lights, l)
+}
+
+// AddNode attaches n to the scene's root node.
+func (s *Scene) AddNode(n *Node) {
+	s.Root.AddChild(n)
+}
+
+// FindNode looks up a node by slash-separated path from the root, falling
+// back to a depth-first search by name.
+func (s *Scene) FindNode(path string) *Node {
+	if n := s.Root.FindPath(path); n != nil && n != s.Root {
+		return n
+	}
+	return s.Root.Find(path)
+}
+
 // ContainsObject :
 func (s *Scene) ContainsObject(o *Object) bool {
 	for _, oo := range s.Objects {
@@ -63,17 +238,3 @@ func (s *Scene) ContainsObject(o *Object) bool {
 	}
 	return false
 }
-
-// func (s *Scene) fillColorBufferWith(color Color) {
-// 	c := color.NRGBA()
-// 	for y := 0; y < s.Height; y++ {
-// 		i := s.ColorBuffer.PixOffset(0, y)
-// 		for x := 0; x < s.Width; x++ {
-// 			s.ColorBuffer.Pix[i+0] = c.R
-// 			s.ColorBuffer.Pix[i+1] = c.G
-// 			s.ColorBuffer.Pix[i+2] = c.B
-// 			s.ColorBuffer.Pix[i+3] = c.A
-// 			i += 4
-// 		}
-// 	}
-// }
@@ -0,0 +1,65 @@
+package fauxgl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScenePick(t *testing.T) {
+	scene := CreateScene(100, 100)
+	left := CreateObject(NewCube(), HexColor("#ff0000"))
+	left.Matrix = Translate(Vector{-1, 0, 0})
+	right := CreateObject(NewCube(), HexColor("#0000ff"))
+	right.Matrix = Translate(Vector{1, 0, 0})
+	scene.AddObject(left)
+	scene.AddObject(right)
+	scene.Render()
+
+	// the camera at z = 10 sees the cube centers this far from the middle
+	offset := int(50 / math.Tan(Radians(15)) / 10)
+	hit := scene.Pick(50-offset, 50)
+	if hit == nil || hit.Object != left {
+		t.Fatalf("Pick(left) = %+v, want the left cube", hit)
+	}
+	if p := hit.Position; math.Abs(p.Z-0.5) > 1e-6 || math.Abs(p.X+1) > 0.5 || math.Abs(p.Y) > 0.5 {
+		t.Errorf("left hit position = %v, want the front face", hit.Position)
+	}
+	if hit.Primitive < 0 || hit.Primitive >= len(left.Mesh.Triangles) {
+		t.Errorf("left hit primitive = %d", hit.Primitive)
+	}
+	hit = scene.Pick(50+offset, 50)
+	if hit == nil || hit.Object != right {
+		t.Fatalf("Pick(right) = %+v, want the right cube", hit)
+	}
+	if hit := scene.Pick(50, 5); hit != nil {
+		t.Errorf("Pick(background) = %+v, want nil", hit)
+	}
+	if hit := scene.Pick(-1, 50); hit != nil {
+		t.Errorf("Pick(outside) = %+v, want nil", hit)
+	}
+	if scene.Context.WriteID {
+		t.Error("Render left WriteID enabled")
+	}
+}
+
+func TestPrimitiveIDs(t *testing.T) {
+	dc := NewContext(64, 64)
+	dc.WriteID = true
+	dc.ObjectID = 7
+	dc.LineWidth = 8
+	triangle := NewTriangleForPoints(Vector{-1, -1, 0}, Vector{-0.2, -1, 0}, Vector{-1, 1, 0})
+	line := NewLineForPoints(Vector{0.5, -1, 0}, Vector{0.5, 1, 0})
+	dc.DrawMesh(NewMesh([]*Triangle{triangle}, []*Line{line}))
+	i := 32*dc.Width + 4
+	if dc.IDBuffer[i] != 7 || dc.PrimitiveBuffer[i] != 0 {
+		t.Errorf("triangle ids = %d, %d, want 7, 0", dc.IDBuffer[i], dc.PrimitiveBuffer[i])
+	}
+	// lines are numbered after the mesh's triangles
+	i = 32*dc.Width + 48
+	if dc.IDBuffer[i] != 7 || dc.PrimitiveBuffer[i] != 1 {
+		t.Errorf("line ids = %d, %d, want 7, 1", dc.IDBuffer[i], dc.PrimitiveBuffer[i])
+	}
+	if dc.IDBuffer[0] != -1 || dc.PrimitiveBuffer[0] != -1 {
+		t.Errorf("background ids = %d, %d, want -1, -1", dc.IDBuffer[0], dc.PrimitiveBuffer[0])
+	}
+}
@@ -8,6 +8,43 @@ type Shader interface {
 	Fragment(Vertex) Color
 }
 
+// preparer is implemented by shaders that precompute state from their
+// fields once per draw call, before vertices are shaded in parallel.
+type preparer interface {
+	prepare()
+}
+
+// normalMatrix transforms vertices from model space into world space.
+// Normals are transformed by the inverse transpose of the model matrix,
+// which keeps them perpendicular to their surface under non-uniform scale,
+// while tangents lie in the surface and follow the model matrix itself.
+// The inverse is computed once per draw call by prepare. A zero model
+// matrix, as left by a shader literal without a Model, is the identity.
+type normalMatrix struct {
+	model  Matrix
+	normal Matrix
+	mirror bool
+}
+
+func (n *normalMatrix) prepare(model Matrix) {
+	*n = normalMatrix{model, model.Inverse().Transpose(), model.Determinant() < 0}
+}
+
+func (n *normalMatrix) transform(v Vertex, model Matrix) Vertex {
+	if model == identity || model == (Matrix{}) {
+		return v
+	}
+	m := *n
+	if m.model != model {
+		// not prepared for this model, as when drawing a single triangle
+		m.prepare(model)
+	}
+	v.Position = model.MulPosition(v.Position)
+	v.Normal = m.normal.MulDirection(v.Normal)
+	v.Tangent = transformTangent(model, v.Tangent, m.mirror)
+	return v
+}
+
 // SolidColorShader renders with a single, solid color.
 type SolidColorShader struct {
 	Matrix Matrix
@@ -49,58 +86,226 @@ func (shader *TextureShader) Vertex(v Vertex) Vertex {
 
 // Fragment :
 func (shader *TextureShader) Fragment(v Vertex) Color {
-	return shader.Texture.BilinearSample(v.Texture.X, v.Texture.Y)
+	return sampleTexture(shader.Texture, v)
 }
 
-// PhongShader implements Phong shading with an optional texture.
+// PhongShader implements Phong shading with an optional texture. Model
+// transforms positions and normals into world space before Matrix is
+// applied, and is the identity if left zero; lighting is computed in world
+// space. If Lights is empty a single white directional light along
+// LightDirection is used, shadowed by ShadowMap when one is set.
+// NormalTexture, if set, is a tangent space normal map; the mesh needs
+// tangents (see Mesh.ComputeTangents).
 type PhongShader struct {
 	Matrix         Matrix
+	Model          Matrix
 	LightDirection Vector
+	Lights         []*Light
 	CameraPosition Vector
 	ObjectColor    Color
 	AmbientColor   Color
 	DiffuseColor   Color
 	SpecularColor  Color
 	Texture        Texture
+	NormalTexture  Texture
 	SpecularPower  float64
+	ShadowMap      *ShadowMap
+	normals        normalMatrix
 }
 
 // NewPhongShader :
 func NewPhongShader(matrix Matrix, lightDirection, cameraPosition Vector) *PhongShader {
-	ambient := Color{0.2, 0.2, 0.2, 1}
-	diffuse := Color{0.8, 0.8, 0.8, 1}
-	specular := Color{1, 1, 1, 1}
 	return &PhongShader{
-		matrix, lightDirection, cameraPosition,
-		Discard, ambient, diffuse, specular, nil, 32}
+		Matrix:         matrix,
+		Model:          Identity(),
+		LightDirection: lightDirection,
+		CameraPosition: cameraPosition,
+		ObjectColor:    Discard,
+		AmbientColor:   Color{0.2, 0.2, 0.2, 1},
+		DiffuseColor:   Color{0.8, 0.8, 0.8, 1},
+		SpecularColor:  Color{1, 1, 1, 1},
+		SpecularPower:  32,
+	}
 }
 
 // Vertex :
 func (shader *PhongShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
 	v.Output = shader.Matrix.MulPositionW(v.Position)
 	return v
 }
 
+func (shader *PhongShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
 // Fragment :
 func (shader *PhongShader) Fragment(v Vertex) Color {
+	if shader.NormalTexture != nil {
+		v.Normal = PerturbNormal(v, shader.NormalTexture)
+	}
 	light := shader.AmbientColor
 	color := v.Color
 	if shader.ObjectColor != Discard {
 		color = shader.ObjectColor
 	}
 	if shader.Texture != nil {
-		color = shader.Texture.BilinearSample(v.Texture.X, v.Texture.Y)
+		color = sampleTexture(shader.Texture, v)
+	}
+	if len(shader.Lights) == 0 {
+		shade := shader.shade(v, shader.LightDirection)
+		if shader.ShadowMap != nil {
+			shade = shade.MulScalar(shader.ShadowMap.Visibility(v.Position))
+		}
+		light = light.Add(shade)
+	} else {
+		for _, l := range shader.Lights {
+			direction, radiance := l.Illuminate(v.Position)
+			light = light.Add(shader.shade(v, direction).Mul(radiance))
+		}
 	}
-	diffuse := math.Max(v.Normal.Dot(shader.LightDirection), 0)
-	light = light.Add(shader.DiffuseColor.MulScalar(diffuse))
+	return color.Mul(light).Alpha(color.A)
+}
+
+func (shader *PhongShader) shade(v Vertex, lightDirection Vector) Color {
+	diffuse := math.Max(v.Normal.Dot(lightDirection), 0)
+	light := shader.DiffuseColor.MulScalar(diffuse)
 	if diffuse > 0 && shader.SpecularPower > 0 {
 		camera := shader.CameraPosition.Sub(v.Position).Normalize()
-		reflected := shader.LightDirection.Negate().Reflect(v.Normal)
+		reflected := lightDirection.Negate().Reflect(v.Normal)
 		specular := math.Max(camera.Dot(reflected), 0)
 		if specular > 0 {
 			specular = math.Pow(specular, shader.SpecularPower)
 			light = light.Add(shader.SpecularColor.MulScalar(specular))
 		}
 	}
-	return color.Mul(light).Min(White).Alpha(color.A)
+	return light
+}
+
+// ReflectionShader renders a surface that reflects, and if IOR is set
+// refracts, an environment. Reflections are blurred by Roughness. With an
+// IOR (index of refraction, e.g. 1.5 for glass) view rays are bent once as
+// they enter the surface, and the refracted and reflected views are mixed
+// by the Fresnel term; otherwise Reflectivity mixes the reflection over
+// Color lit by the environment. Results are tinted by Tint.
+type ReflectionShader struct {
+	Matrix         Matrix
+	Model          Matrix
+	CameraPosition Vector
+	Environment    *Environment
+	Color          Color
+	Tint           Color
+	Reflectivity   float64
+	Roughness      float64
+	IOR            float64
+	normals        normalMatrix
+}
+
+// NewReflectionShader :
+func NewReflectionShader(matrix Matrix, cameraPosition Vector, environment *Environment) *ReflectionShader {
+	return &ReflectionShader{
+		Matrix:         matrix,
+		Model:          Identity(),
+		CameraPosition: cameraPosition,
+		Environment:    environment,
+		Color:          White,
+		Tint:           White,
+		Reflectivity:   1,
+	}
+}
+
+// Vertex :
+func (shader *ReflectionShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
+	v.Output = shader.Matrix.MulPositionW(v.Position)
+	return v
+}
+
+func (shader *ReflectionShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
+// Fragment :
+func (shader *ReflectionShader) Fragment(v Vertex) Color {
+	e := shader.Environment
+	n := v.Normal
+	incident := v.Position.Sub(shader.CameraPosition).Normalize()
+	if n.Dot(incident) > 0 {
+		n = n.Negate()
+	}
+	reflected := e.Radiance(incident.Reflect(n), shader.Roughness)
+	var c Color
+	if shader.IOR > 0 {
+		// Schlick's approximation of the Fresnel reflectance
+		f0 := (1 - shader.IOR) / (1 + shader.IOR)
+		f0 *= f0
+		f := f0 + (1-f0)*math.Pow(1+n.Dot(incident), 5)
+		refracted := reflected
+		if r := incident.Refract(n, 1/shader.IOR); r != (Vector{}) {
+			refracted = e.Radiance(r, shader.Roughness)
+		} else {
+			f = 1
+		}
+		c = refracted.Lerp(reflected, f)
+	} else {
+		c = reflected
+		if shader.Reflectivity < 1 {
+			diffuse := shader.Color.Mul(e.Irradiance(n))
+			c = diffuse.Lerp(reflected, shader.Reflectivity)
+		}
+	}
+	return c.Mul(shader.Tint).Alpha(1)
+}
+
+// G-buffer outputs, in the order GBufferShader writes them :
+const (
+	GBufferAlbedo = iota
+	GBufferNormal
+	GBufferPosition
+	GBufferMaterial
+)
+
+// GBufferShader shades like its PhongShader and also writes the surface's
+// unlit albedo, world space normal, world space position and MaterialID (in
+// every channel) as outputs, for deferred shading or compositing. Add
+// attachments to the context in the order of the GBuffer constants; outputs
+// without an attachment are dropped. Only the albedo is a color: set the
+// Encoding of the position and material attachments to EncodingLinear.
+type GBufferShader struct {
+	*PhongShader
+	MaterialID float64
+}
+
+// NewGBufferShader :
+func NewGBufferShader(phong *PhongShader, materialID float64) *GBufferShader {
+	return &GBufferShader{phong, materialID}
+}
+
+// FragmentOutputs :
+func (shader *GBufferShader) FragmentOutputs(v Vertex, outputs []Color) Color {
+	color := shader.Fragment(v)
+	if color == Discard {
+		return Discard
+	}
+	albedo := v.Color
+	if shader.ObjectColor != Discard {
+		albedo = shader.ObjectColor
+	}
+	if shader.Texture != nil {
+		albedo = sampleTexture(shader.Texture, v)
+	}
+	n := v.Normal.Normalize()
+	if shader.NormalTexture != nil {
+		n = PerturbNormal(v, shader.NormalTexture)
+	}
+	p := v.Position
+	id := shader.MaterialID
+	gbuffer := [...]Color{
+		GBufferAlbedo:   albedo,
+		GBufferNormal:   Color{n.X, n.Y, n.Z, 1},
+		GBufferPosition: Color{p.X, p.Y, p.Z, 1},
+		GBufferMaterial: Color{id, id, id, 1},
+	}
+	copy(outputs, gbuffer[:])
+	return color
 }
@@ -0,0 +1,34 @@
+package fauxgl
+
+import "testing"
+
+func TestPhongShaderZeroModel(t *testing.T) {
+	dc := NewContext(32, 32)
+	dc.Shader = &PhongShader{
+		Matrix:         Identity(),
+		LightDirection: Vector{0, 0, 1},
+		CameraPosition: Vector{0, 0, 1},
+		ObjectColor:    White,
+		DiffuseColor:   White,
+	}
+	info := dc.DrawMesh(NewPlane())
+	if info.TotalPixels == 0 {
+		t.Error("a shader without Model drew nothing")
+	}
+}
+
+func TestNormalMatrix(t *testing.T) {
+	// a plane sloped at 45 degrees, squashed along X, must keep its normal
+	// perpendicular to the surface
+	model := Scale(Vector{0.5, 1, 1})
+	var n normalMatrix
+	n.prepare(model)
+	v := n.transform(Vertex{Normal: Vector{1, 0, 1}.Normalize()}, model)
+	edge := model.MulDirection(Vector{1, 0, -1})
+	if d := v.Normal.Dot(edge); d > 1e-9 || d < -1e-9 {
+		t.Errorf("normal %v is not perpendicular to %v", v.Normal, edge)
+	}
+	if d := v.Normal.Length(); d < 1-1e-9 || d > 1+1e-9 {
+		t.Errorf("normal %v is not unit length", v.Normal)
+	}
+}
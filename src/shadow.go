@@ -0,0 +1,86 @@
+package fauxgl
+
+import "math"
+
+// ShadowMap is a depth texture rendered from a light's point of view. Draw
+// occluders into it with DrawMesh, then attach it to a PhongShader or Light
+// so that fragments hidden from the light are left in shadow.
+//
+// Bias is subtracted from a fragment's light-space depth before comparing it
+// against the map, to avoid self-shadowing. Filter is the radius in texels of
+// the percentage-closer filtering kernel; 0 gives hard shadows.
+type ShadowMap struct {
+	Width   int
+	Height  int
+	Matrix  Matrix
+	Bias    float64
+	Filter  int
+	Context *Context
+}
+
+// NewShadowMap :
+func NewShadowMap(width, height int, matrix Matrix) *ShadowMap {
+	dc := NewContext(width, height)
+	dc.WriteColor = false
+	dc.AlphaBlend = false
+	dc.Cull = CullNone
+	return &ShadowMap{width, height, matrix, 0.005, 1, dc}
+}
+
+// DirectionalShadowMatrix returns an orthographic light matrix that covers
+// box, for a directional light shining from direction (which points towards
+// the light, as with PhongShader.LightDirection).
+func DirectionalShadowMatrix(direction Vector, box Box) Matrix {
+	direction = direction.Normalize()
+	up := Vector{0, 0, 1}
+	if math.Abs(direction.Z) > 0.99 {
+		up = Vector{0, 1, 0}
+	}
+	center := box.Center()
+	r := box.Size().Length() / 2
+	eye := center.Add(direction.MulScalar(r * 2))
+	return LookAt(eye, center, up).Orthographic(-r, r, -r, r, r, r*3)
+}
+
+// Clear resets the depth texture.
+func (sm *ShadowMap) Clear() {
+	sm.Context.ClearDepthBuffer()
+}
+
+// DrawMesh renders the depth of mesh, transformed by model, into the map.
+func (sm *ShadowMap) DrawMesh(mesh *Mesh, model Matrix) RasterizeInfo {
+	sm.Context.Shader = NewSolidColorShader(sm.Matrix.Mul(model), White)
+	return sm.Context.DrawTriangles(mesh.Triangles)
+}
+
+// Depth returns the depth stored at texel (x, y).
+func (sm *ShadowMap) Depth(x, y int) float64 {
+	return sm.Context.DepthBuffer[y*sm.Width+x]
+}
+
+// Visibility returns the fraction of the filter kernel around the world-space
+// position that is visible from the light, from 0 (in shadow) to 1 (lit).
+// Positions outside the light's view volume are considered lit.
+func (sm *ShadowMap) Visibility(position Vector) float64 {
+	p := sm.Matrix.MulPositionW(position)
+	if p.Outside() {
+		return 1
+	}
+	s := sm.Context.screenMatrix.MulPosition(p.DivScalar(p.W).Vector())
+	x := int(math.Floor(s.X))
+	y := int(math.Floor(s.Y))
+	z := s.Z - sm.Bias
+	r := sm.Filter
+	var lit, total int
+	for dy := -r; dy <= r; dy++ {
+		yy := ClampInt(y+dy, 0, sm.Height-1)
+		for dx := -r; dx <= r; dx++ {
+			xx := ClampInt(x+dx, 0, sm.Width-1)
+			if z <= sm.Depth(xx, yy) {
+				lit++
+			}
+			total++
+		}
+	}
+	return float64(lit) / float64(total)
+}
@@ -0,0 +1,229 @@
+package fauxgl
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SSAO configures the screen-space ambient occlusion post-process. Matrix
+// must be the matrix that the scene was drawn with (for a Scene, the
+// camera's Matrix), so that depths can be turned back into world space
+// positions. Radius and Bias are in world units and Blur is in output
+// pixels.
+//
+// Normals, if set, should hold world space normals such as those written
+// by GBufferShader; otherwise they are estimated from the depth buffer.
+type SSAO struct {
+	Matrix   Matrix
+	Radius   float64
+	Samples  int
+	Blur     int
+	Bias     float64
+	Strength float64
+	Normals  *Attachment
+}
+
+// NewSSAO :
+func NewSSAO(matrix Matrix) *SSAO {
+	return &SSAO{
+		Matrix:   matrix,
+		Radius:   0.5,
+		Samples:  16,
+		Blur:     2,
+		Bias:     0.025,
+		Strength: 1,
+	}
+}
+
+// ssaoNoise is the size of the tiled pattern of kernel rotations.
+const ssaoNoise = 4
+
+// AmbientOcclusion computes the ambient occlusion at every sample of the
+// depth buffer, from 0 (fully occluded) to 1. Pixels where nothing was
+// drawn are 1. The result is deterministic.
+func (dc *Context) AmbientOcclusion(ssao *SSAO) []float64 {
+	w, h := dc.Width, dc.Height
+	project := dc.screenMatrix.Mul(ssao.Matrix)
+	unproject := ssao.Matrix.Inverse().Mul(dc.screenMatrix.Inverse())
+	position := func(x, y int) (Vector, bool) {
+		z := dc.DepthBuffer[y*w+x]
+		if z == math.MaxFloat64 {
+			return Vector{}, false
+		}
+		p := unproject.MulPositionW(Vector{float64(x) + 0.5, float64(y) + 0.5, z})
+		return p.DivScalar(p.W).Vector(), true
+	}
+
+	// hemisphere kernel around +Z, denser near the center, and a tiled set
+	// of rotations to decorrelate neighboring pixels
+	rnd := rand.New(rand.NewSource(1))
+	kernel := make([]Vector, ssao.Samples)
+	for i := range kernel {
+		v := Vector{rnd.Float64()*2 - 1, rnd.Float64()*2 - 1, rnd.Float64()}
+		s := float64(i) / float64(len(kernel))
+		kernel[i] = v.Normalize().MulScalar(rnd.Float64() * (0.1 + 0.9*s*s))
+	}
+	var noise [ssaoNoise * ssaoNoise]Vector
+	for i := range noise {
+		noise[i] = Vector{rnd.Float64()*2 - 1, rnd.Float64()*2 - 1, 0}
+	}
+
+	ao := make([]float64, w*h)
+	parallelRows(h, func(y int) {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			ao[i] = 1
+			p, ok := position(x, y)
+			if !ok {
+				continue
+			}
+			var n Vector
+			if ssao.Normals != nil {
+				c := ssao.Normals.Buffer[i]
+				n = Vector{c.R, c.G, c.B}.Normalize()
+			} else {
+				n = dc.estimateNormal(x, y, p, unproject, position)
+			}
+			if n == (Vector{}) {
+				continue
+			}
+			// orient the kernel along the normal with a per pixel rotation
+			r := noise[(y%ssaoNoise)*ssaoNoise+x%ssaoNoise]
+			t := r.Sub(n.MulScalar(r.Dot(n)))
+			if t.Length() < 1e-9 {
+				t = n.Perpendicular()
+			}
+			t = t.Normalize()
+			b := n.Cross(t)
+			origin := p.Add(n.MulScalar(ssao.Bias))
+			var occlusion float64
+			for _, k := range kernel {
+				d := t.MulScalar(k.X).Add(b.MulScalar(k.Y)).Add(n.MulScalar(k.Z))
+				s := origin.Add(d.MulScalar(ssao.Radius))
+				q := project.MulPositionW(s)
+				q = q.DivScalar(q.W)
+				sx := int(math.Floor(q.X))
+				sy := int(math.Floor(q.Y))
+				if sx < 0 || sy < 0 || sx >= w || sy >= h {
+					continue
+				}
+				if dc.DepthBuffer[sy*w+sx] >= q.Z {
+					continue
+				}
+				// ignore occluders far outside the radius
+				o, _ := position(sx, sy)
+				dist := o.Sub(p).Length()
+				occlusion += smoothstep(0, 1, ssao.Radius/math.Max(dist, 1e-9))
+			}
+			a := 1 - ssao.Strength*occlusion/float64(len(kernel))
+			ao[i] = Clamp(a, 0, 1)
+		}
+	})
+	return dc.blurOcclusion(ao, ssao.Blur*dc.Scale)
+}
+
+// ApplyAmbientOcclusion computes ambient occlusion and multiplies it into
+// the color buffer, or into the HDR buffer for HDR contexts.
+func (dc *Context) ApplyAmbientOcclusion(ssao *SSAO) {
+	dc.CompositeFragments()
+	ao := dc.AmbientOcclusion(ssao)
+	parallelRows(dc.Height, func(y int) {
+		for x := 0; x < dc.Width; x++ {
+			i := y*dc.Width + x
+			a := ao[i]
+			if a >= 1 {
+				continue
+			}
+			if dc.HDR && dc.HDRBuffer != nil {
+				c := dc.HDRBuffer[i]
+				dc.HDRBuffer[i] = c.MulScalar(a).Alpha(c.A)
+				continue
+			}
+			c := dc.decode(x, y)
+			dc.ColorBuffer.SetNRGBA(x, y, dc.encode(c.MulScalar(a).Alpha(c.A)))
+		}
+	})
+}
+
+// estimateNormal reconstructs a world space normal from the positions of
+// neighboring depth samples, using the nearer neighbor on each axis to
+// avoid smearing across silhouettes. The normal faces the camera.
+func (dc *Context) estimateNormal(x, y int, p Vector, unproject Matrix, position func(x, y int) (Vector, bool)) Vector {
+	neighbor := func(x0, y0, x1, y1 int) (Vector, bool) {
+		var best Vector
+		found := false
+		for _, c := range [][2]int{{x0, y0}, {x1, y1}} {
+			if c[0] < 0 || c[1] < 0 || c[0] >= dc.Width || c[1] >= dc.Height {
+				continue
+			}
+			q, ok := position(c[0], c[1])
+			if !ok {
+				continue
+			}
+			d := q.Sub(p)
+			if c == [2]int{x0, y0} {
+				d = d.Negate()
+			}
+			if !found || d.Length() < best.Length() {
+				best = d
+				found = true
+			}
+		}
+		return best, found
+	}
+	dx, okx := neighbor(x-1, y, x+1, y)
+	dy, oky := neighbor(x, y-1, x, y+1)
+	if !okx || !oky {
+		return Vector{}
+	}
+	n := dy.Cross(dx).Normalize()
+	// flip the normal if it faces away from the viewer, whose direction is
+	// found by unprojecting the same pixel on the near plane
+	near := unproject.MulPositionW(Vector{float64(x) + 0.5, float64(y) + 0.5, 0})
+	if n.Dot(p.Sub(near.DivScalar(near.W).Vector())) > 0 {
+		n = n.Negate()
+	}
+	return n
+}
+
+// blurOcclusion box blurs ao with the given radius in samples, ignoring
+// pixels where nothing was drawn.
+func (dc *Context) blurOcclusion(ao []float64, radius int) []float64 {
+	if radius <= 0 {
+		return ao
+	}
+	w, h := dc.Width, dc.Height
+	pass := func(src []float64, horizontal bool) []float64 {
+		dst := make([]float64, len(src))
+		parallelRows(h, func(y int) {
+			for x := 0; x < w; x++ {
+				i := y*w + x
+				if dc.DepthBuffer[i] == math.MaxFloat64 {
+					dst[i] = src[i]
+					continue
+				}
+				var sum, count float64
+				for k := -radius; k <= radius; k++ {
+					xx, yy := x, y
+					if horizontal {
+						xx += k
+					} else {
+						yy += k
+					}
+					if xx < 0 || yy < 0 || xx >= w || yy >= h {
+						continue
+					}
+					j := yy*w + xx
+					if dc.DepthBuffer[j] == math.MaxFloat64 {
+						continue
+					}
+					sum += src[j]
+					count++
+				}
+				dst[i] = sum / count
+			}
+		})
+		return dst
+	}
+	return pass(pass(ao, true), false)
+}
@@ -0,0 +1,116 @@
+package fauxgl
+
+// StencilFunc :
+type StencilFunc int
+
+// StencilFuncs :
+const (
+	_ StencilFunc = iota
+	StencilNever
+	StencilLess
+	StencilEqual
+	StencilLEqual
+	StencilGreater
+	StencilNotEqual
+	StencilGEqual
+	StencilAlways
+)
+
+// Test compares the masked reference value against the masked stored value,
+// in that order, so StencilLess passes when ref < value.
+func (f StencilFunc) Test(ref, value uint8) bool {
+	switch f {
+	case StencilNever:
+		return false
+	case StencilLess:
+		return ref < value
+	case StencilEqual:
+		return ref == value
+	case StencilLEqual:
+		return ref <= value
+	case StencilGreater:
+		return ref > value
+	case StencilNotEqual:
+		return ref != value
+	case StencilGEqual:
+		return ref >= value
+	}
+	return true
+}
+
+// StencilOp :
+type StencilOp int
+
+// StencilOps :
+const (
+	_ StencilOp = iota
+	StencilKeep
+	StencilZero
+	StencilReplace
+	StencilIncr
+	StencilIncrWrap
+	StencilDecr
+	StencilDecrWrap
+	StencilInvert
+)
+
+// Apply returns the new stencil value for a stored value and reference.
+func (op StencilOp) Apply(value, ref uint8) uint8 {
+	switch op {
+	case StencilZero:
+		return 0
+	case StencilReplace:
+		return ref
+	case StencilIncr:
+		if value < 0xff {
+			return value + 1
+		}
+	case StencilIncrWrap:
+		return value + 1
+	case StencilDecr:
+		if value > 0 {
+			return value - 1
+		}
+	case StencilDecrWrap:
+		return value - 1
+	case StencilInvert:
+		return ^value
+	}
+	return value
+}
+
+// ClearStencilBufferWith sets every value in the stencil buffer, allocating
+// it if needed.
+func (dc *Context) ClearStencilBufferWith(value uint8) {
+	if dc.StencilBuffer == nil {
+		dc.StencilBuffer = make([]uint8, dc.Width*dc.Height)
+	}
+	for i := range dc.StencilBuffer {
+		dc.StencilBuffer[i] = value
+	}
+}
+
+// ClearStencilBuffer :
+func (dc *Context) ClearStencilBuffer() {
+	dc.ClearStencilBufferWith(0)
+}
+
+// stencilTest reports whether the stencil test passes at index i, applying
+// StencilFail if it does not.
+func (dc *Context) stencilTest(i int) bool {
+	ref := dc.StencilRef & dc.StencilMask
+	if dc.StencilFunc.Test(ref, dc.StencilBuffer[i]&dc.StencilMask) {
+		return true
+	}
+	dc.stencilOp(i, dc.StencilFail)
+	return false
+}
+
+func (dc *Context) stencilOp(i int, op StencilOp) {
+	if op == StencilKeep {
+		return
+	}
+	s := dc.StencilBuffer[i]
+	v := op.Apply(s, dc.StencilRef)
+	dc.StencilBuffer[i] = s&^dc.StencilWriteMask | v&dc.StencilWriteMask
+}
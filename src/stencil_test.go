@@ -0,0 +1,125 @@
+package fauxgl
+
+import "testing"
+
+func TestStencilOpApply(t *testing.T) {
+	cases := []struct {
+		op    StencilOp
+		value uint8
+		want  uint8
+	}{
+		{StencilKeep, 7, 7},
+		{StencilZero, 7, 0},
+		{StencilReplace, 7, 3},
+		{StencilIncr, 7, 8},
+		{StencilIncr, 0xff, 0xff},
+		{StencilIncrWrap, 0xff, 0},
+		{StencilDecr, 7, 6},
+		{StencilDecr, 0, 0},
+		{StencilDecrWrap, 0, 0xff},
+		{StencilInvert, 0x0f, 0xf0},
+	}
+	for _, c := range cases {
+		if got := c.op.Apply(c.value, 3); got != c.want {
+			t.Errorf("op %d: Apply(%d, 3) = %d, want %d", c.op, c.value, got, c.want)
+		}
+	}
+}
+
+func TestStencilFuncTest(t *testing.T) {
+	funcs := []struct {
+		f                  StencilFunc
+		less, equal, great bool
+	}{
+		{StencilNever, false, false, false},
+		{StencilLess, true, false, false},
+		{StencilEqual, false, true, false},
+		{StencilLEqual, true, true, false},
+		{StencilGreater, false, false, true},
+		{StencilNotEqual, true, false, true},
+		{StencilGEqual, false, true, true},
+		{StencilAlways, true, true, true},
+	}
+	for _, c := range funcs {
+		if c.f.Test(1, 2) != c.less || c.f.Test(2, 2) != c.equal || c.f.Test(3, 2) != c.great {
+			t.Errorf("func %d gives %v %v %v, want %v %v %v", c.f,
+				c.f.Test(1, 2), c.f.Test(2, 2), c.f.Test(3, 2), c.less, c.equal, c.great)
+		}
+	}
+}
+
+func countStencil(dc *Context, value uint8) int {
+	n := 0
+	for _, v := range dc.StencilBuffer {
+		if v == value {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStencilDraw(t *testing.T) {
+	// unclipped single triangles, so that no pixel is covered twice; the
+	// large one contains the small one
+	small := NewTriangleMesh([]*Triangle{NewTriangleForPoints(
+		Vector{-0.5, -0.5, 0}, Vector{0.5, -0.5, 0}, Vector{-0.5, 0.5, 0})})
+	large := NewTriangleMesh([]*Triangle{NewTriangleForPoints(
+		Vector{-0.9, -0.9, 0}, Vector{0.9, -0.9, 0}, Vector{-0.9, 0.9, 0})})
+	behind := small.Copy()
+	behind.Transform(Translate(Vector{0, 0, 0.5}))
+
+	dc := NewContext(16, 16)
+	dc.StencilTest = true
+	dc.StencilRef = 1
+	dc.StencilPass = StencilReplace
+	dc.DrawMesh(small)
+	inside := countStencil(dc, 1)
+	outside := int(NewContext(16, 16).DrawMesh(large).TotalPixels) - inside
+	if inside == 0 || outside <= 0 {
+		t.Fatalf("replace: %d pixels set", inside)
+	}
+	untouched := 16*16 - inside - outside
+
+	// only pixels that pass the stencil test are drawn and counted
+	dc.ClearDepthBuffer()
+	dc.StencilFunc = StencilEqual
+	dc.StencilPass = StencilIncr
+	dc.StencilFail = StencilInvert
+	info := dc.DrawMesh(large)
+	if info.TotalPixels != uint64(inside) || info.UpdatedPixels != uint64(inside) {
+		t.Errorf("equal: info = %+v, want %d pixels", info, inside)
+	}
+	if n := countStencil(dc, 2); n != inside {
+		t.Errorf("pass op: %d pixels incremented, want %d", n, inside)
+	}
+	if n := countStencil(dc, 0xff); n != outside {
+		t.Errorf("fail op: %d pixels inverted, want %d", n, outside)
+	}
+
+	// the depth fail op applies where the stencil test passes but the depth
+	// test does not
+	dc.StencilFunc = StencilAlways
+	dc.StencilFail = StencilKeep
+	dc.StencilPass = StencilKeep
+	dc.StencilDepthFail = StencilZero
+	info = dc.DrawMesh(behind)
+	if info.TotalPixels != uint64(inside) || info.UpdatedPixels != 0 {
+		t.Errorf("depth fail: info = %+v, want %d pixels, none updated", info, inside)
+	}
+	if n := countStencil(dc, 0); n != inside+untouched {
+		t.Errorf("depth fail op: %d pixels at zero, want %d", n, inside+untouched)
+	}
+
+	// only bits in the write mask are changed
+	dc.ClearDepthBuffer()
+	dc.StencilRef = 0xff
+	dc.StencilWriteMask = 0x0f
+	dc.StencilPass = StencilReplace
+	dc.DrawMesh(large)
+	if n := countStencil(dc, 0x0f); n != inside {
+		t.Errorf("write mask: %d pixels set to 0x0f, want %d", n, inside)
+	}
+	if n := countStencil(dc, 0xff); n != outside {
+		t.Errorf("write mask: %d pixels at 0xff, want %d", n, outside)
+	}
+}
@@ -0,0 +1,234 @@
+package fauxgl
+
+import "math"
+
+// ToonShader implements cel shading. The diffuse term of each light is
+// quantized into flat bands: Bands holds ascending thresholds on the cosine
+// between the normal and the light, and a surface past k of n thresholds
+// gets k / n of the light. If Ramp is set it replaces the bands, and is
+// looked up horizontally by the half-Lambert term, (N.L + 1) / 2, with
+// nearest filtering. A hard specular highlight from LightDirection, or the
+// first of Lights, is added where the Phong term exceeds one half, unless
+// SpecularPower is zero.
+//
+// Colors come from ObjectColor, vertex colors or Texture as in PhongShader,
+// and Lights are handled the same way.
+type ToonShader struct {
+	Matrix         Matrix
+	Model          Matrix
+	LightDirection Vector
+	Lights         []*Light
+	CameraPosition Vector
+	ObjectColor    Color
+	AmbientColor   Color
+	DiffuseColor   Color
+	SpecularColor  Color
+	Texture        Texture
+	Bands          []float64
+	Ramp           Texture
+	SpecularPower  float64
+	normals        normalMatrix
+}
+
+// NewToonShader :
+func NewToonShader(matrix Matrix, lightDirection, cameraPosition Vector) *ToonShader {
+	return &ToonShader{
+		Matrix:         matrix,
+		Model:          Identity(),
+		LightDirection: lightDirection,
+		CameraPosition: cameraPosition,
+		ObjectColor:    Discard,
+		AmbientColor:   Color{0.2, 0.2, 0.2, 1},
+		DiffuseColor:   Color{0.8, 0.8, 0.8, 1},
+		SpecularColor:  White,
+		Bands:          []float64{0, 0.5},
+	}
+}
+
+// Vertex :
+func (shader *ToonShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
+	v.Output = shader.Matrix.MulPositionW(v.Position)
+	return v
+}
+
+func (shader *ToonShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
+// Fragment :
+func (shader *ToonShader) Fragment(v Vertex) Color {
+	color := v.Color
+	if shader.ObjectColor != Discard {
+		color = shader.ObjectColor
+	}
+	if shader.Texture != nil {
+		color = sampleTexture(shader.Texture, v)
+	}
+	light := shader.AmbientColor
+	if len(shader.Lights) == 0 {
+		light = light.Add(shader.shade(v, shader.LightDirection).Mul(shader.DiffuseColor))
+	} else {
+		for _, l := range shader.Lights {
+			direction, radiance := l.Illuminate(v.Position)
+			light = light.Add(shader.shade(v, direction).Mul(shader.DiffuseColor).Mul(radiance))
+		}
+	}
+	color = color.Mul(light).Alpha(color.A)
+	if shader.SpecularPower > 0 {
+		direction := shader.LightDirection
+		if len(shader.Lights) > 0 {
+			direction, _ = shader.Lights[0].Illuminate(v.Position)
+		}
+		camera := shader.CameraPosition.Sub(v.Position).Normalize()
+		reflected := direction.Negate().Reflect(v.Normal)
+		specular := math.Pow(math.Max(camera.Dot(reflected), 0), shader.SpecularPower)
+		if specular > 0.5 && v.Normal.Dot(direction) > 0 {
+			color = color.Add(shader.SpecularColor).Alpha(color.A)
+		}
+	}
+	return color
+}
+
+// shade returns the quantized diffuse light for a light direction.
+func (shader *ToonShader) shade(v Vertex, lightDirection Vector) Color {
+	d := v.Normal.Dot(lightDirection)
+	if shader.Ramp != nil {
+		// nearest filtering keeps the ramp's bands crisp
+		u := Clamp((d+1)/2, 0, 1-1e-9)
+		return shader.Ramp.Sample(u, 0.5)
+	}
+	if len(shader.Bands) == 0 {
+		return Gray(math.Max(d, 0))
+	}
+	var k int
+	for _, t := range shader.Bands {
+		if d > t {
+			k++
+		}
+	}
+	return Gray(float64(k) / float64(len(shader.Bands)))
+}
+
+// GoochShader implements Gooch shading for technical illustration, which
+// conveys shape with a shift from a cool color facing away from the light
+// to a warm color facing it instead of with shading from light to dark. The
+// object color is mixed into the Cool and Warm tones by Alpha and Beta, and
+// a white highlight is added unless SpecularPower is zero. There is a single
+// light, along LightDirection.
+type GoochShader struct {
+	Matrix         Matrix
+	Model          Matrix
+	LightDirection Vector
+	CameraPosition Vector
+	ObjectColor    Color
+	Cool           Color
+	Warm           Color
+	Alpha          float64
+	Beta           float64
+	SpecularPower  float64
+	normals        normalMatrix
+}
+
+// NewGoochShader :
+func NewGoochShader(matrix Matrix, lightDirection, cameraPosition Vector) *GoochShader {
+	return &GoochShader{
+		Matrix:         matrix,
+		Model:          Identity(),
+		LightDirection: lightDirection,
+		CameraPosition: cameraPosition,
+		ObjectColor:    Discard,
+		Cool:           Color{0, 0, 0.55, 1},
+		Warm:           Color{0.3, 0.3, 0, 1},
+		Alpha:          0.25,
+		Beta:           0.5,
+		SpecularPower:  32,
+	}
+}
+
+// Vertex :
+func (shader *GoochShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
+	v.Output = shader.Matrix.MulPositionW(v.Position)
+	return v
+}
+
+func (shader *GoochShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
+// Fragment :
+func (shader *GoochShader) Fragment(v Vertex) Color {
+	color := v.Color
+	if shader.ObjectColor != Discard {
+		color = shader.ObjectColor
+	}
+	cool := shader.Cool.Add(color.MulScalar(shader.Alpha))
+	warm := shader.Warm.Add(color.MulScalar(shader.Beta))
+	t := (1 + v.Normal.Dot(shader.LightDirection)) / 2
+	result := cool.Lerp(warm, t)
+	if shader.SpecularPower > 0 {
+		camera := shader.CameraPosition.Sub(v.Position).Normalize()
+		reflected := shader.LightDirection.Negate().Reflect(v.Normal)
+		specular := math.Pow(math.Max(camera.Dot(reflected), 0), shader.SpecularPower)
+		result = result.Lerp(White, specular)
+	}
+	return result.Alpha(color.A)
+}
+
+// MatcapShader shades with a matcap (material capture), an image of a lit
+// sphere as seen by the camera, which is looked up by the normal as seen
+// from the camera. This bakes lighting and material into one texture, so it
+// is independent of the scene's lights. Up is the camera's up vector, and
+// the result is tinted by ObjectColor.
+type MatcapShader struct {
+	Matrix         Matrix
+	Model          Matrix
+	CameraPosition Vector
+	Up             Vector
+	Texture        Texture
+	ObjectColor    Color
+	normals        normalMatrix
+}
+
+// NewMatcapShader :
+func NewMatcapShader(matrix Matrix, cameraPosition, up Vector, texture Texture) *MatcapShader {
+	return &MatcapShader{
+		Matrix:         matrix,
+		Model:          Identity(),
+		CameraPosition: cameraPosition,
+		Up:             up,
+		Texture:        texture,
+		ObjectColor:    White,
+	}
+}
+
+// Vertex :
+func (shader *MatcapShader) Vertex(v Vertex) Vertex {
+	v = shader.normals.transform(v, shader.Model)
+	v.Output = shader.Matrix.MulPositionW(v.Position)
+	return v
+}
+
+func (shader *MatcapShader) prepare() {
+	shader.normals.prepare(shader.Model)
+}
+
+// Fragment :
+func (shader *MatcapShader) Fragment(v Vertex) Color {
+	// build the view basis at each point rather than once for the camera,
+	// which keeps the lookup consistent towards the edges of wide views
+	z := shader.CameraPosition.Sub(v.Position).Normalize()
+	x := shader.Up.Cross(z)
+	if x.Length() < 1e-9 {
+		x = z.Perpendicular()
+	}
+	x = x.Normalize()
+	y := z.Cross(x)
+	n := v.Normal
+	// stay just inside the sphere's rim, which is often antialiased
+	u := n.Dot(x)*0.49 + 0.5
+	w := n.Dot(y)*0.49 + 0.5
+	c := shader.Texture.BilinearSample(u, w)
+	return c.Mul(shader.ObjectColor).Alpha(c.A * shader.ObjectColor.A)
+}
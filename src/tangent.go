@@ -0,0 +1,119 @@
+package fauxgl
+
+import "math"
+
+// ComputeTangents generates per-vertex tangents from the mesh's texture
+// coordinates and normals, for use with normal maps. Following MikkTSpace,
+// each face's tangent and bitangent are weighted by the angle of the face
+// at the vertex and accumulated across faces that share the position,
+// normal and texture coordinate of the vertex, keeping mirrored UVs apart;
+// the tangent is then made orthogonal to the normal. Faces without usable
+// texture coordinates get an arbitrary tangent perpendicular to the normal.
+func (m *Mesh) ComputeTangents() {
+	type key struct {
+		position, normal, texture Vector
+		flip                      bool
+	}
+	type frame struct {
+		tangent, bitangent Vector
+	}
+	lookup := make(map[key]frame)
+	keys := make([][3]key, len(m.Triangles))
+	for i, t := range m.Triangles {
+		v := [3]*Vertex{&t.V1, &t.V2, &t.V3}
+		e1 := t.V2.Position.Sub(t.V1.Position)
+		e2 := t.V3.Position.Sub(t.V1.Position)
+		d1 := t.V2.Texture.Sub(t.V1.Texture)
+		d2 := t.V3.Texture.Sub(t.V1.Texture)
+		r := d1.X*d2.Y - d2.X*d1.Y
+		for j := range v {
+			keys[i][j] = key{v[j].Position, v[j].Normal, v[j].Texture, r < 0}
+		}
+		if math.Abs(r) < 1e-12 {
+			continue
+		}
+		tangent := e1.MulScalar(d2.Y).Sub(e2.MulScalar(d1.Y)).DivScalar(r)
+		bitangent := e2.MulScalar(d1.X).Sub(e1.MulScalar(d2.X)).DivScalar(r)
+		if tangent.Length() < 1e-12 || bitangent.Length() < 1e-12 {
+			continue
+		}
+		tangent = tangent.Normalize()
+		bitangent = bitangent.Normalize()
+		for j := range v {
+			a := v[(j+1)%3].Position.Sub(v[j].Position)
+			b := v[(j+2)%3].Position.Sub(v[j].Position)
+			if a.Length() < 1e-12 || b.Length() < 1e-12 {
+				continue
+			}
+			angle := math.Acos(Clamp(a.Normalize().Dot(b.Normalize()), -1, 1))
+			f := lookup[keys[i][j]]
+			f.tangent = f.tangent.Add(tangent.MulScalar(angle))
+			f.bitangent = f.bitangent.Add(bitangent.MulScalar(angle))
+			lookup[keys[i][j]] = f
+		}
+	}
+	for i, t := range m.Triangles {
+		v := [3]*Vertex{&t.V1, &t.V2, &t.V3}
+		for j := range v {
+			f := lookup[keys[i][j]]
+			v[j].Tangent = orthogonalTangent(v[j].Normal, f.tangent, f.bitangent)
+		}
+	}
+}
+
+// orthogonalTangent makes tangent orthogonal to the normal n and returns it
+// with the handedness of the bitangent in W.
+func orthogonalTangent(n, tangent, bitangent Vector) VectorW {
+	t := tangent.Sub(n.MulScalar(n.Dot(tangent)))
+	if t.Length() < 1e-9 {
+		t = n.Perpendicular()
+	}
+	t = t.Normalize()
+	w := 1.0
+	if n.Cross(t).Dot(bitangent) < 0 {
+		w = -1
+	}
+	return VectorW{t.X, t.Y, t.Z, w}
+}
+
+// transformTangent transforms the tangent part of t as a direction, flipping
+// its handedness if the matrix mirrors.
+func transformTangent(m Matrix, t VectorW, mirror bool) VectorW {
+	if t == (VectorW{}) {
+		return t
+	}
+	v := m.MulDirection(t.Vector())
+	w := t.W
+	if mirror {
+		w = -w
+	}
+	return VectorW{v.X, v.Y, v.Z, w}
+}
+
+// PerturbNormal returns the normal of v perturbed by a tangent space normal
+// map, whose colors encode normals as n * 0.5 + 0.5 with +Y along increasing
+// V. The map should be loaded as linear data (see LoadLinearTexture) and
+// v must carry a Tangent. Vertices without one are returned unchanged.
+func PerturbNormal(v Vertex, normalMap Texture) Vector {
+	if v.Tangent == (VectorW{}) {
+		return v.Normal
+	}
+	c := sampleTexture(normalMap, v)
+	m := Vector{c.R*2 - 1, c.G*2 - 1, c.B*2 - 1}
+	n := v.Normal.Normalize()
+	t := v.Tangent.Vector()
+	t = t.Sub(n.MulScalar(n.Dot(t)))
+	if t.Length() < 1e-9 {
+		return n
+	}
+	t = t.Normalize()
+	b := n.Cross(t)
+	if v.Tangent.W < 0 {
+		b = b.Negate()
+	}
+	p := t.MulScalar(m.X).Add(b.MulScalar(m.Y)).Add(n.MulScalar(m.Z))
+	if p.Length() < 1e-9 {
+		return n
+	}
+	return p.Normalize()
+}
@@ -2,6 +2,7 @@ package fauxgl
 
 import (
 	"image"
+	"image/draw"
 	"math"
 )
 
@@ -11,7 +12,38 @@ type Texture interface {
 	BilinearSample(u, v float64) Color
 }
 
-// LoadTexture :
+// MipmapTexture is a Texture that can also be sampled with mipmapping,
+// choosing its level of detail from the screen-space derivatives of the
+// texture coordinates. Shaders use MipmapSample for textures that implement
+// it, and BilinearSample for any other Texture.
+type MipmapTexture interface {
+	Texture
+	LOD(dx, dy Vector) float64
+	MipmapSample(u, v float64, dx, dy Vector) Color
+}
+
+// sampleTexture samples t at the texture coordinates of v, with mipmapping
+// if t supports it.
+func sampleTexture(t Texture, v Vertex) Color {
+	if m, ok := t.(MipmapTexture); ok {
+		return m.MipmapSample(v.Texture.X, v.Texture.Y, v.TextureDx, v.TextureDy)
+	}
+	return t.BilinearSample(v.Texture.X, v.Texture.Y)
+}
+
+// Wrap :
+type Wrap int
+
+// Wraps :
+const (
+	_ Wrap = iota
+	WrapRepeat
+	WrapClamp
+	WrapMirror
+	WrapBorder
+)
+
+// LoadTexture loads an sRGB encoded color texture.
 func LoadTexture(path string) (Texture, error) {
 	im, err := LoadImage(path)
 	if err != nil {
@@ -20,50 +52,257 @@ func LoadTexture(path string) (Texture, error) {
 	return NewImageTexture(im), nil
 }
 
+// LoadLinearTexture loads a texture holding linear data, such as a normal
+// map, which is sampled without sRGB decoding.
+func LoadLinearTexture(path string) (Texture, error) {
+	im, err := LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewLinearImageTexture(im), nil
+}
+
 // ImageTexture :
+//
+// The image is converted to a packed, straight alpha NRGBA buffer when the
+// texture is created, along with a chain of successively halved mipmap
+// levels, so that sampling indexes directly into memory. Samples are
+// premultiplied by alpha, like MakeColor. Wrap controls how coordinates
+// outside of [0, 1] are handled; with WrapBorder, such samples return
+// BorderColor.
+//
+// Textures created with NewImageTexture are sRGB encoded; their texels are
+// decoded to linear intensities before they are premultiplied and filtered,
+// and mipmaps are averaged in linear space.
 type ImageTexture struct {
-	Width  int
-	Height int
-	Image  image.Image
+	Width       int
+	Height      int
+	Image       image.Image
+	Wrap        Wrap
+	BorderColor Color
+	levels      []*image.NRGBA
+	srgb        bool
 }
 
-// NewImageTexture :
+// NewImageTexture creates a texture from an sRGB encoded color image.
 func NewImageTexture(im image.Image) Texture {
-	size := im.Bounds().Max
-	return &ImageTexture{size.X, size.Y, im}
+	return newImageTexture(im, true)
+}
+
+// NewLinearImageTexture creates a texture from an image holding linear data.
+func NewLinearImageTexture(im image.Image) Texture {
+	return newImageTexture(im, false)
+}
+
+func newImageTexture(im image.Image, srgb bool) Texture {
+	size := im.Bounds().Size()
+	return &ImageTexture{
+		Width:  size.X,
+		Height: size.Y,
+		Image:  im,
+		Wrap:   WrapRepeat,
+		levels: mipmaps(toNRGBA(im), srgb),
+		srgb:   srgb,
+	}
+}
+
+func toNRGBA(im image.Image) *image.NRGBA {
+	bounds := im.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	if src, ok := im.(*image.NRGBA); ok {
+		// copy rows directly, as a conversion through premultiplied
+		// colors would lose precision at low alpha
+		n := bounds.Dx() * 4
+		for y := 0; y < bounds.Dy(); y++ {
+			i := src.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			copy(dst.Pix[y*dst.Stride:y*dst.Stride+n], src.Pix[i:i+n])
+		}
+		return dst
+	}
+	draw.Draw(dst, dst.Bounds(), im, bounds.Min, draw.Src)
+	return dst
+}
+
+// linearTable maps 8-bit values to themselves, for textures that are not
+// sRGB encoded.
+var linearTable = func() (table [256]float64) {
+	for i := range table {
+		table[i] = float64(i) / 0xff
+	}
+	return
+}()
+
+func colorTable(srgb bool) *[256]float64 {
+	if srgb {
+		return &srgbTable
+	}
+	return &linearTable
+}
+
+// texelColor returns the premultiplied color of a straight alpha texel,
+// decoding its color channels with lut first.
+func texelColor(p []uint8, lut *[256]float64) Color {
+	a := linearTable[p[3]]
+	return Color{lut[p[0]] * a, lut[p[1]] * a, lut[p[2]] * a, a}
+}
+
+func mipmaps(im *image.NRGBA, srgb bool) []*image.NRGBA {
+	lut := colorTable(srgb)
+	levels := []*image.NRGBA{im}
+	for {
+		sw, sh := im.Rect.Dx(), im.Rect.Dy()
+		if sw <= 1 && sh <= 1 {
+			return levels
+		}
+		w := (sw + 1) / 2
+		h := (sh + 1) / 2
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			y0 := ClampInt(y*2, 0, sh-1) * im.Stride
+			y1 := ClampInt(y*2+1, 0, sh-1) * im.Stride
+			for x := 0; x < w; x++ {
+				x0 := ClampInt(x*2, 0, sw-1) * 4
+				x1 := ClampInt(x*2+1, 0, sw-1) * 4
+				// average premultiplied colors so that transparent texels
+				// do not bleed their color into the result
+				var c Color
+				for _, j := range [4]int{y0 + x0, y0 + x1, y1 + x0, y1 + x1} {
+					c = c.Add(texelColor(im.Pix[j:j+4:j+4], lut))
+				}
+				c = c.MulScalar(0.25)
+				if c.A > 0 {
+					c = c.DivScalar(c.A).Alpha(c.A)
+				}
+				if srgb {
+					c = Color{linearToSRGB(c.R), linearToSRGB(c.G), linearToSRGB(c.B), c.A}
+				}
+				i := y*dst.Stride + x*4
+				p := dst.Pix[i : i+4 : i+4]
+				for k, v := range [4]float64{c.R, c.G, c.B, c.A} {
+					p[k] = uint8(Clamp(v, 0, 1)*0xff + 0.5)
+				}
+			}
+		}
+		levels = append(levels, dst)
+		im = dst
+	}
+}
+
+func wrapIndex(i, n int, wrap Wrap) (int, bool) {
+	switch wrap {
+	case WrapClamp:
+		return ClampInt(i, 0, n-1), true
+	case WrapBorder:
+		return i, i >= 0 && i < n
+	case WrapMirror:
+		p := n * 2
+		i %= p
+		if i < 0 {
+			i += p
+		}
+		if i >= n {
+			i = p - 1 - i
+		}
+		return i, true
+	default:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	}
+}
+
+func (t *ImageTexture) texel(level, x, y int) Color {
+	im := t.levels[level]
+	x, okx := wrapIndex(x, im.Rect.Dx(), t.Wrap)
+	y, oky := wrapIndex(y, im.Rect.Dy(), t.Wrap)
+	if !okx || !oky {
+		return t.BorderColor
+	}
+	i := y*im.Stride + x*4
+	return texelColor(im.Pix[i:i+4:i+4], colorTable(t.srgb))
+}
+
+func (t *ImageTexture) bilinear(level int, u, v float64) Color {
+	im := t.levels[level]
+	v = 1 - v
+	x := u*float64(im.Rect.Dx()) - 0.5
+	y := v*float64(im.Rect.Dy()) - 0.5
+	fx := math.Floor(x)
+	fy := math.Floor(y)
+	x0 := int(fx)
+	y0 := int(fy)
+	x -= fx
+	y -= fy
+	w00 := (1 - x) * (1 - y)
+	w10 := x * (1 - y)
+	w01 := (1 - x) * y
+	w11 := x * y
+	var c00, c01, c10, c11 Color
+	if x0 >= 0 && y0 >= 0 && x0+1 < im.Rect.Dx() && y0+1 < im.Rect.Dy() {
+		// fast path: all four texels are inside the image
+		lut := colorTable(t.srgb)
+		i := y0*im.Stride + x0*4
+		j := i + im.Stride
+		c00 = texelColor(im.Pix[i:i+4:i+4], lut)
+		c10 = texelColor(im.Pix[i+4:i+8:i+8], lut)
+		c01 = texelColor(im.Pix[j:j+4:j+4], lut)
+		c11 = texelColor(im.Pix[j+4:j+8:j+8], lut)
+	} else {
+		c00 = t.texel(level, x0, y0)
+		c01 = t.texel(level, x0, y0+1)
+		c10 = t.texel(level, x0+1, y0)
+		c11 = t.texel(level, x0+1, y0+1)
+	}
+	c := Color{}
+	c = c.Add(c00.MulScalar(w00))
+	c = c.Add(c10.MulScalar(w10))
+	c = c.Add(c01.MulScalar(w01))
+	c = c.Add(c11.MulScalar(w11))
+	return c
 }
 
 // Sample :
 func (t *ImageTexture) Sample(u, v float64) Color {
 	v = 1 - v
-	u -= math.Floor(u)
-	v -= math.Floor(v)
-	x := int(u * float64(t.Width))
-	y := int(v * float64(t.Height))
-	return MakeColor(t.Image.At(x, y))
+	x := int(math.Floor(u * float64(t.Width)))
+	y := int(math.Floor(v * float64(t.Height)))
+	return t.texel(0, x, y)
 }
 
 // BilinearSample :
 func (t *ImageTexture) BilinearSample(u, v float64) Color {
-	v = 1 - v
-	u -= math.Floor(u)
-	v -= math.Floor(v)
-	x := u * float64(t.Width-1)
-	y := v * float64(t.Height-1)
-	x0 := int(x)
-	y0 := int(y)
-	x1 := x0 + 1
-	y1 := y0 + 1
-	x -= float64(x0)
-	y -= float64(y0)
-	c00 := MakeColor(t.Image.At(x0, y0))
-	c01 := MakeColor(t.Image.At(x0, y1))
-	c10 := MakeColor(t.Image.At(x1, y0))
-	c11 := MakeColor(t.Image.At(x1, y1))
-	c := Color{}
-	c = c.Add(c00.MulScalar((1 - x) * (1 - y)))
-	c = c.Add(c10.MulScalar(x * (1 - y)))
-	c = c.Add(c01.MulScalar((1 - x) * y))
-	c = c.Add(c11.MulScalar(x * y))
-	return c
+	return t.bilinear(0, u, v)
+}
+
+// LOD returns the mipmap level of detail for the given screen-space
+// derivatives of the texture coordinates.
+func (t *ImageTexture) LOD(dx, dy Vector) float64 {
+	w := float64(t.Width)
+	h := float64(t.Height)
+	x := math.Hypot(dx.X*w, dx.Y*h)
+	y := math.Hypot(dy.X*w, dy.Y*h)
+	rho := math.Max(x, y)
+	if rho <= 0 {
+		return 0
+	}
+	return math.Log2(rho)
+}
+
+// MipmapSample performs trilinear filtering, choosing the mipmap levels from
+// the screen-space derivatives of the texture coordinates.
+func (t *ImageTexture) MipmapSample(u, v float64, dx, dy Vector) Color {
+	lod := t.LOD(dx, dy)
+	n := len(t.levels) - 1
+	if lod <= 0 || n == 0 {
+		return t.bilinear(0, u, v)
+	}
+	if lod >= float64(n) {
+		return t.bilinear(n, u, v)
+	}
+	l := int(lod)
+	c0 := t.bilinear(l, u, v)
+	c1 := t.bilinear(l+1, u, v)
+	return c0.Lerp(c1, lod-float64(l))
 }
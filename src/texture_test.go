@@ -0,0 +1,118 @@
+package fauxgl
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTextureSRGBAlpha(t *testing.T) {
+	for _, c := range []color.NRGBA{
+		{255, 255, 255, 128},
+		{188, 64, 0, 255},
+		{188, 64, 0, 64},
+		{10, 200, 100, 1},
+	} {
+		texture := NewImageTexture(uniformImage(4, 4, c)).(*ImageTexture)
+		want := MakeColor(c)
+		samples := map[string]Color{
+			"Sample":         texture.Sample(0.3, 0.6),
+			"BilinearSample": texture.BilinearSample(0.5, 0.5),
+			"bilinear edge":  texture.BilinearSample(0, 0),
+			"MipmapSample":   texture.MipmapSample(0.5, 0.5, Vector{0.5, 0, 0}, Vector{0, 0.5, 0}),
+		}
+		for name, got := range samples {
+			if !colorsEqual(got, want, 1e-2) {
+				t.Errorf("%s of %v = %v, want %v", name, c, got, want)
+			}
+		}
+	}
+}
+
+func TestTextureImageTypes(t *testing.T) {
+	// images that are not NRGBA are converted when the texture is made
+	c := color.NRGBA{200, 100, 50, 128}
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			rgba.Set(x, y, c)
+			gray.SetGray(x, y, color.Gray{100})
+		}
+	}
+	cases := []struct {
+		im   image.Image
+		want Color
+	}{
+		{rgba, MakeColor(c)},
+		{gray, MakeColor(color.Gray{100})},
+	}
+	for _, c := range cases {
+		got := NewImageTexture(c.im).BilinearSample(0.5, 0.5)
+		if !colorsEqual(got, c.want, 1e-2) {
+			t.Errorf("%T: BilinearSample = %v, want %v", c.im, got, c.want)
+		}
+	}
+}
+
+func TestLinearTexture(t *testing.T) {
+	c := color.NRGBA{128, 255, 0, 128}
+	texture := NewLinearImageTexture(uniformImage(2, 2, c))
+	a := 128.0 / 255
+	want := Color{a * a, a, 0, a}
+	if got := texture.BilinearSample(0.5, 0.5); !colorsEqual(got, want, 1e-9) {
+		t.Errorf("BilinearSample = %v, want %v", got, want)
+	}
+}
+
+func TestTextureMipmapPremultiplied(t *testing.T) {
+	// a single opaque red texel among transparent green ones must not pick
+	// up any green when averaged
+	im := uniformImage(2, 2, color.NRGBA{0, 255, 0, 0})
+	im.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	texture := NewImageTexture(im).(*ImageTexture)
+	got := texture.MipmapSample(0.5, 0.5, Vector{4, 0, 0}, Vector{0, 4, 0})
+	want := Color{0.25, 0, 0, 0.25}
+	if !colorsEqual(got, want, 1e-2) {
+		t.Errorf("MipmapSample = %v, want %v", got, want)
+	}
+}
+
+func TestTextureWrap(t *testing.T) {
+	im := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	im.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	im.SetNRGBA(1, 0, color.NRGBA{0, 0, 255, 255})
+	texture := NewImageTexture(im).(*ImageTexture)
+	red := Color{1, 0, 0, 1}
+	blue := Color{0, 0, 1, 1}
+	cases := []struct {
+		wrap Wrap
+		u    float64
+		want Color
+	}{
+		{WrapRepeat, 1.25, red},
+		{WrapClamp, 1.25, blue},
+		{WrapMirror, 1.25, blue},
+		{WrapBorder, 1.25, Transparent},
+	}
+	for _, c := range cases {
+		texture.Wrap = c.wrap
+		if got := texture.Sample(c.u, 0.5); !colorsEqual(got, c.want, 1e-9) {
+			t.Errorf("wrap %d: Sample(%g) = %v, want %v", c.wrap, c.u, got, c.want)
+		}
+	}
+}
+
+// constantTexture is a Texture without mipmaps.
+type constantTexture Color
+
+func (t constantTexture) Sample(u, v float64) Color         { return Color(t) }
+func (t constantTexture) BilinearSample(u, v float64) Color { return Color(t) }
+
+func TestSampleTextureWithoutMipmaps(t *testing.T) {
+	want := Color{0.5, 0.25, 0, 1}
+	v := Vertex{Texture: Vector{0.5, 0.5, 0}, TextureDx: Vector{1, 0, 0}}
+	if got := sampleTexture(constantTexture(want), v); got != want {
+		t.Errorf("sampleTexture = %v, want %v", got, want)
+	}
+}
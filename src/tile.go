@@ -0,0 +1,132 @@
+package fauxgl
+
+import (
+	"image"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// tileSize is the width and height in pixels of the screen tiles that
+// primitives are binned into.
+const tileSize = 64
+
+// primitive is a triangle that has been through the vertex shader, clipping
+// and culling, with its screen space coordinates. Lines and wireframes are
+// drawn as pairs of these.
+type primitive struct {
+	v0, v1, v2 Vertex
+	s0, s1, s2 Vector
+	index      int32
+}
+
+// bin holds the primitives produced by one geometry worker, along with the
+// indexes of the primitives overlapping each tile, in submission order.
+type bin struct {
+	primitives []primitive
+	tiles      [][]int32
+}
+
+// draw renders n primitives using setup to transform the ith one, which is
+// recorded in the primitive buffer as first + i.
+//
+// Drawing happens in two parallel phases. First the input is split into
+// contiguous runs, one per worker, which are set up and binned into screen
+// tiles. Then the workers take tiles one at a time and rasterize every
+// primitive overlapping them, in the order they were submitted. Each pixel
+// is only ever touched by the goroutine that owns its tile, so no locking
+// is needed and the output does not depend on scheduling.
+func (dc *Context) draw(n, first int, setup func(i int, primitives []primitive) []primitive) RasterizeInfo {
+	if n == 0 {
+		return RasterizeInfo{}
+	}
+	if p, ok := dc.Shader.(preparer); ok {
+		p.prepare()
+	}
+	cols := (dc.Width + tileSize - 1) / tileSize
+	rows := (dc.Height + tileSize - 1) / tileSize
+	wn := runtime.NumCPU()
+
+	// geometry
+	bn := wn
+	if bn > n {
+		bn = n
+	}
+	bins := make([]*bin, bn)
+	var wg sync.WaitGroup
+	for bi := 0; bi < bn; bi++ {
+		wg.Add(1)
+		go func(bi int) {
+			defer wg.Done()
+			lo, hi := bi*n/bn, (bi+1)*n/bn
+			b := &bin{
+				primitives: make([]primitive, 0, hi-lo),
+				tiles:      make([][]int32, cols*rows),
+			}
+			for i := lo; i < hi; i++ {
+				start := len(b.primitives)
+				b.primitives = setup(i, b.primitives)
+				for j := start; j < len(b.primitives); j++ {
+					b.primitives[j].index = int32(first + i)
+					dc.binPrimitive(b, j, cols, rows)
+				}
+			}
+			bins[bi] = b
+		}(bi)
+	}
+	wg.Wait()
+
+	// rasterization
+	dc.allocateBuffers()
+	var next int64
+	ch := make(chan RasterizeInfo, wn)
+	for wi := 0; wi < wn; wi++ {
+		go func() {
+			var result RasterizeInfo
+			outputs := dc.outputs()
+			for {
+				t := int(atomic.AddInt64(&next, 1) - 1)
+				if t >= cols*rows {
+					break
+				}
+				x := (t % cols) * tileSize
+				y := (t / cols) * tileSize
+				bounds := image.Rect(x, y, x+tileSize, y+tileSize)
+				bounds = bounds.Intersect(image.Rect(0, 0, dc.Width, dc.Height))
+				for _, b := range bins {
+					for _, j := range b.tiles[t] {
+						info := dc.rasterize(&b.primitives[j], bounds, outputs)
+						result = result.Add(info)
+					}
+				}
+			}
+			ch <- result
+		}()
+	}
+	var result RasterizeInfo
+	for wi := 0; wi < wn; wi++ {
+		result = result.Add(<-ch)
+	}
+	return result
+}
+
+// binPrimitive adds the jth primitive of b to every tile that its bounding
+// box overlaps.
+func (dc *Context) binPrimitive(b *bin, j int, cols, rows int) {
+	p := &b.primitives[j]
+	min := p.s0.Min(p.s1.Min(p.s2)).Floor()
+	max := p.s0.Max(p.s1.Max(p.s2)).Ceil()
+	if max.X < 0 || max.Y < 0 || min.X >= float64(dc.Width) || min.Y >= float64(dc.Height) {
+		return
+	}
+	tx0 := ClampInt(int(min.X)/tileSize, 0, cols-1)
+	tx1 := ClampInt(int(max.X)/tileSize, 0, cols-1)
+	ty0 := ClampInt(int(min.Y)/tileSize, 0, rows-1)
+	ty1 := ClampInt(int(max.Y)/tileSize, 0, rows-1)
+	for ty := ty0; ty <= ty1; ty++ {
+		for tx := tx0; tx <= tx1; tx++ {
+			t := ty*cols + tx
+			b.tiles[t] = append(b.tiles[t], int32(j))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package fauxgl
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestDrawDeterministic(t *testing.T) {
+	// many overlapping translucent triangles across several tiles, whose
+	// blended result depends on the order in which each pixel sees them
+	rnd := rand.New(rand.NewSource(1))
+	point := func() Vector {
+		return Vector{rnd.Float64()*2.4 - 1.2, rnd.Float64()*2.4 - 1.2, rnd.Float64()*2 - 1}
+	}
+	var triangles []*Triangle
+	for i := 0; i < 100; i++ {
+		t := NewTriangleForPoints(point(), point(), point())
+		t.SetColor(Color{rnd.Float64(), rnd.Float64(), rnd.Float64(), 0.5})
+		triangles = append(triangles, t)
+	}
+	render := func(parallel bool) *Context {
+		dc := NewContext(160, 120)
+		dc.Cull = CullNone
+		dc.ReadDepth = false
+		dc.WriteID = true
+		dc.Shader = NewPhongShader(Identity(), Vector{0, 0, 1}, Vector{0, 0, 2})
+		if parallel {
+			dc.DrawTriangles(triangles)
+		} else {
+			for _, t := range triangles {
+				dc.DrawTriangle(t)
+			}
+		}
+		return dc
+	}
+	want := render(false)
+	for i := 0; i < 3; i++ {
+		got := render(true)
+		if !bytes.Equal(got.ColorBuffer.Pix, want.ColorBuffer.Pix) {
+			t.Fatalf("draw %d: colors differ from drawing one triangle at a time", i)
+		}
+		for j := range want.DepthBuffer {
+			if got.DepthBuffer[j] != want.DepthBuffer[j] {
+				t.Fatalf("draw %d: depth differs at %d", i, j)
+			}
+		}
+	}
+}
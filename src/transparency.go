@@ -0,0 +1,88 @@
+package fauxgl
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Transparency selects how translucent fragments are combined when
+// AlphaBlend is enabled.
+type Transparency int
+
+// Transparencies :
+//
+// TransparencyOrdered blends each fragment as it is drawn, in the order its
+// primitive was submitted. TransparencyOrderIndependent keeps a list of the
+// translucent fragments at each pixel and blends them back to front when
+// CompositeFragments is called, so overlapping surfaces composite correctly
+// regardless of draw order.
+const (
+	_ Transparency = iota
+	TransparencyOrdered
+	TransparencyOrderIndependent
+)
+
+// fragment is a translucent fragment along with the depth and blend state
+// that it was drawn with, so that it composites the same way whatever the
+// state is when CompositeFragments is called.
+type fragment struct {
+	Depth     float64
+	TestDepth float64
+	DepthFunc DepthFunc
+	Blend     Blend
+	Color     Color
+}
+
+func (dc *Context) addFragment(i int, z, bz float64, color Color) {
+	f := fragment{z, bz, dc.DepthFunc, dc.blend(), color}
+	if !dc.ReadDepth {
+		f.DepthFunc = DepthAlways
+	}
+	dc.fragments[i] = append(dc.fragments[i], f)
+}
+
+func (dc *Context) clearFragments() {
+	for i := range dc.fragments {
+		dc.fragments[i] = dc.fragments[i][:0]
+	}
+}
+
+// CompositeFragments blends the translucent fragments collected in
+// TransparencyOrderIndependent mode into the color buffer, farthest first,
+// and clears them. Fragments that fail the depth test against opaque
+// geometry drawn after them are dropped. Fragments at equal depth keep
+// their draw order. Each fragment is blended with the Blend, and tested
+// with the DepthFunc, that was set when it was drawn.
+func (dc *Context) CompositeFragments() {
+	if dc.fragments == nil {
+		return
+	}
+	wn := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for wi := 0; wi < wn; wi++ {
+		wg.Add(1)
+		go func(wi int) {
+			defer wg.Done()
+			for y := wi; y < dc.Height; y += wn {
+				for x := 0; x < dc.Width; x++ {
+					i := y*dc.Width + x
+					fragments := dc.fragments[i]
+					if len(fragments) == 0 {
+						continue
+					}
+					sort.SliceStable(fragments, func(a, b int) bool {
+						return fragments[a].Depth > fragments[b].Depth
+					})
+					for _, f := range fragments {
+						if f.DepthFunc.Test(f.TestDepth, dc.DepthBuffer[i]) {
+							dc.writeColor(x, y, f.Color, f.Blend)
+						}
+					}
+					dc.fragments[i] = fragments[:0]
+				}
+			}
+		}(wi)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+package fauxgl
+
+import "testing"
+
+// translucentTriangle returns a triangle at depth z that covers pixel 3, 8
+// of a 16x16 context with an identity matrix.
+func translucentTriangle(z float64, color Color) *Triangle {
+	t := NewTriangleForPoints(Vector{-0.9, -0.9, z}, Vector{0.9, -0.9, z}, Vector{-0.9, 0.9, z})
+	t.SetColor(color)
+	return t
+}
+
+func drawTransparent(transparency Transparency, triangles ...*Triangle) Color {
+	dc := NewContext(16, 16)
+	dc.ClearColorBufferWith(White)
+	dc.Transparency = transparency
+	dc.Shader = &vertexColorShader{}
+	for _, t := range triangles {
+		dc.DrawTriangle(t)
+	}
+	dc.CompositeFragments()
+	return dc.decode(3, 8)
+}
+
+func TestCompositeFragmentsBlendState(t *testing.T) {
+	// each fragment composites with the blend it was drawn with, not the
+	// one set when CompositeFragments runs
+	red := translucentTriangle(0, Color{0.5, 0, 0, 0.5})
+	draw := func(transparency Transparency) Color {
+		dc := NewContext(16, 16)
+		dc.ClearColorBufferWith(Color{0, 0, 0.5, 1})
+		dc.Transparency = transparency
+		dc.Shader = &vertexColorShader{}
+		dc.Blend = BlendAdditive
+		dc.DrawTriangle(red)
+		dc.Blend = BlendSourceOver
+		dc.CompositeFragments()
+		return dc.decode(3, 8)
+	}
+	want := draw(TransparencyOrdered)
+	if got := draw(TransparencyOrderIndependent); !colorsEqual(got, want, 1e-2) {
+		t.Errorf("composited %v, want %v", got, want)
+	}
+}
+
+func TestCompositeFragmentsOrder(t *testing.T) {
+	red := translucentTriangle(-0.5, Color{0.5, 0, 0, 0.5})
+	blue := translucentTriangle(0.5, Color{0, 0, 0.5, 0.5})
+	// drawn back to front, ordered blending is correct
+	want := drawTransparent(TransparencyOrdered, blue, red)
+	if wrong := drawTransparent(TransparencyOrdered, red, blue); colorsEqual(wrong, want, 1e-2) {
+		t.Fatalf("blending order makes no difference: %v", want)
+	}
+	for _, order := range [][]*Triangle{{blue, red}, {red, blue}} {
+		got := drawTransparent(TransparencyOrderIndependent, order...)
+		if !colorsEqual(got, want, 1e-2) {
+			t.Errorf("composited %v, want %v", got, want)
+		}
+	}
+
+	// fragments at the same depth keep their draw order
+	green := translucentTriangle(0.5, Color{0, 0.5, 0, 0.5})
+	want = drawTransparent(TransparencyOrdered, blue, green)
+	if got := drawTransparent(TransparencyOrderIndependent, blue, green); !colorsEqual(got, want, 1e-2) {
+		t.Errorf("equal depths composited %v, want %v", got, want)
+	}
+
+	// opaque geometry drawn afterwards hides fragments behind it
+	opaque := translucentTriangle(0, Color{0, 1, 0, 1})
+	want = drawTransparent(TransparencyOrdered, opaque, red)
+	if got := drawTransparent(TransparencyOrderIndependent, blue, red, opaque); !colorsEqual(got, want, 1e-2) {
+		t.Errorf("occluded fragments composited %v, want %v", got, want)
+	}
+}
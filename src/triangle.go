@@ -79,6 +79,10 @@ func (t *Triangle) Transform(matrix Matrix) {
 	t.V1.Normal = matrix.MulDirection(t.V1.Normal)
 	t.V2.Normal = matrix.MulDirection(t.V2.Normal)
 	t.V3.Normal = matrix.MulDirection(t.V3.Normal)
+	mirror := matrix.Determinant() < 0
+	t.V1.Tangent = transformTangent(matrix, t.V1.Tangent, mirror)
+	t.V2.Tangent = transformTangent(matrix, t.V2.Tangent, mirror)
+	t.V3.Tangent = transformTangent(matrix, t.V3.Tangent, mirror)
 }
 
 // ReverseWinding :
@@ -87,6 +91,10 @@ func (t *Triangle) ReverseWinding() {
 	t.V1.Normal = t.V1.Normal.Negate()
 	t.V2.Normal = t.V2.Normal.Negate()
 	t.V3.Normal = t.V3.Normal.Negate()
+	// keep the bitangents pointing the same way
+	t.V1.Tangent.W = -t.V1.Tangent.W
+	t.V2.Tangent.W = -t.V2.Tangent.W
+	t.V3.Tangent.W = -t.V3.Tangent.W
 }
 
 // SetColor :
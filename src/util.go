@@ -10,8 +10,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Radians :
@@ -45,6 +47,8 @@ func LoadMesh(path string) (*Mesh, error) {
 		return LoadPLY(path)
 	case ".3ds":
 		return Load3DS(path)
+	case ".gltf", ".glb":
+		return LoadGLTF(path)
 	}
 	return nil, fmt.Errorf("unrecognized mesh extension: %s", ext)
 }
@@ -125,3 +129,19 @@ func RoundPlaces(a float64, places int) float64 {
 }
 
 var powersOfTen = []float64{1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16}
+
+// parallelRows calls fn for every row in [0, n) across all CPUs.
+func parallelRows(n int, fn func(y int)) {
+	wn := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for wi := 0; wi < wn; wi++ {
+		wg.Add(1)
+		go func(wi int) {
+			defer wg.Done()
+			for y := wi; y < n; y += wn {
+				fn(y)
+			}
+		}(wi)
+	}
+	wg.Wait()
+}
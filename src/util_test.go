@@ -0,0 +1,57 @@
+package fauxgl
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func colorsEqual(a, b Color, tolerance float64) bool {
+	return math.Abs(a.R-b.R) <= tolerance && math.Abs(a.G-b.G) <= tolerance &&
+		math.Abs(a.B-b.B) <= tolerance && math.Abs(a.A-b.A) <= tolerance
+}
+
+func uniformImage(w, h int, c color.NRGBA) *image.NRGBA {
+	im := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			im.SetNRGBA(x, y, c)
+		}
+	}
+	return im
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fauxgl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func vectorsEqual(a, b Vector, tolerance float64) bool {
+	return a.Sub(b).Abs().MaxComponent() <= tolerance
+}
+
+// vertexColorShader shades with unlit vertex colors.
+type vertexColorShader struct{}
+
+func (shader *vertexColorShader) Vertex(v Vertex) Vertex {
+	v.Output = v.Position.VectorW()
+	return v
+}
+
+func (shader *vertexColorShader) Fragment(v Vertex) Color {
+	return v.Color
+}
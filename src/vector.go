@@ -207,6 +207,18 @@ func (a Vector) Reflect(n Vector) Vector {
 	return a.Sub(n.MulScalar(2 * n.Dot(a)))
 }
 
+// Refract refracts the unit vector a through a surface with unit normal n,
+// where eta is the ratio of the refractive indices on either side. It
+// returns the zero vector on total internal reflection.
+func (a Vector) Refract(n Vector, eta float64) Vector {
+	d := n.Dot(a)
+	k := 1 - eta*eta*(1-d*d)
+	if k < 0 {
+		return Vector{}
+	}
+	return a.MulScalar(eta).Sub(n.MulScalar(eta*d + math.Sqrt(k)))
+}
+
 // Perpendicular :
 func (a Vector) Perpendicular() Vector {
 	if a.X == 0 && a.Y == 0 {
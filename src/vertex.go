@@ -1,15 +1,33 @@
 package fauxgl
 
 // Vertex :
+//
+// Vectors, Colors and Floats are optional varyings that a Shader can fill in
+// its Vertex stage to pass extra data to its Fragment stage. They are
+// interpolated perspective-correctly along with the other attributes, and
+// nil slices cost nothing. All three vertices of a primitive must carry the
+// same number of each. Vertex stages should assign new slices rather than
+// writing into existing ones, as mesh vertices may share them.
+//
+// TextureDx and TextureDy are the screen-space derivatives of Texture. They
+// are filled in by the rasterizer for textured primitives, for use in
+// MipmapTexture.MipmapSample.
+//
+// Tangent is the tangent space basis used for normal mapping, as generated
+// by Mesh.ComputeTangents: XYZ is the tangent and W (+1 or -1) is the
+// handedness, so that the bitangent is Normal.Cross(Tangent) * W.
 type Vertex struct {
-	Position Vector
-	Normal   Vector
-	Texture  Vector
-	Color    Color
-	Output   VectorW
-	// Vectors  []Vector
-	// Colors   []Color
-	// Floats   []float64
+	Position  Vector
+	Normal    Vector
+	Tangent   VectorW
+	Texture   Vector
+	TextureDx Vector
+	TextureDy Vector
+	Color     Color
+	Output    VectorW
+	Vectors   []Vector
+	Colors    []Color
+	Floats    []float64
 }
 
 // Outside :
@@ -22,30 +40,31 @@ func InterpolateVertexes(v1, v2, v3 Vertex, b VectorW) Vertex {
 	v := Vertex{}
 	v.Position = InterpolateVectors(v1.Position, v2.Position, v3.Position, b)
 	v.Normal = InterpolateVectors(v1.Normal, v2.Normal, v3.Normal, b).Normalize()
+	v.Tangent = InterpolateVectorWs(v1.Tangent, v2.Tangent, v3.Tangent, b)
 	v.Texture = InterpolateVectors(v1.Texture, v2.Texture, v3.Texture, b)
 	v.Color = InterpolateColors(v1.Color, v2.Color, v3.Color, b)
 	v.Output = InterpolateVectorWs(v1.Output, v2.Output, v3.Output, b)
-	// if v1.Vectors != nil {
-	// 	v.Vectors = make([]Vector, len(v1.Vectors))
-	// 	for i := range v.Vectors {
-	// 		v.Vectors[i] = InterpolateVectors(
-	// 			v1.Vectors[i], v2.Vectors[i], v3.Vectors[i], b)
-	// 	}
-	// }
-	// if v1.Colors != nil {
-	// 	v.Colors = make([]Color, len(v1.Colors))
-	// 	for i := range v.Colors {
-	// 		v.Colors[i] = InterpolateColors(
-	// 			v1.Colors[i], v2.Colors[i], v3.Colors[i], b)
-	// 	}
-	// }
-	// if v1.Floats != nil {
-	// 	v.Floats = make([]float64, len(v1.Floats))
-	// 	for i := range v.Floats {
-	// 		v.Floats[i] = InterpolateFloats(
-	// 			v1.Floats[i], v2.Floats[i], v3.Floats[i], b)
-	// 	}
-	// }
+	if v1.Vectors != nil {
+		v.Vectors = make([]Vector, len(v1.Vectors))
+		for i := range v.Vectors {
+			v.Vectors[i] = InterpolateVectors(
+				v1.Vectors[i], v2.Vectors[i], v3.Vectors[i], b)
+		}
+	}
+	if v1.Colors != nil {
+		v.Colors = make([]Color, len(v1.Colors))
+		for i := range v.Colors {
+			v.Colors[i] = InterpolateColors(
+				v1.Colors[i], v2.Colors[i], v3.Colors[i], b)
+		}
+	}
+	if v1.Floats != nil {
+		v.Floats = make([]float64, len(v1.Floats))
+		for i := range v.Floats {
+			v.Floats[i] = InterpolateFloats(
+				v1.Floats[i], v2.Floats[i], v3.Floats[i], b)
+		}
+	}
 	return v
 }
 
@@ -55,7 +55,7 @@ func LoadVOX(path string) ([]Voxel, error) {
 		g := float64((x>>8)&255) / 255
 		b := float64((x>>16)&255) / 255
 		a := float64((x>>24)&255) / 255
-		palette[i] = Color{r, g, b, a}
+		palette[i] = Color{r, g, b, a}.Linear()
 	}
 
 	for {
@@ -92,7 +92,7 @@ func LoadVOX(path string) ([]Voxel, error) {
 				g := float64(color[1]) / 255
 				b := float64(color[2]) / 255
 				a := float64(color[3]) / 255
-				palette[i+1] = Color{r, g, b, a}
+				palette[i+1] = Color{r, g, b, a}.Linear()
 			}
 		default:
 			file.Seek(int64(chunk.ContentBytes), 1)